@@ -0,0 +1,116 @@
+package calcu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Script is a parsed script, i.e., the statements of the lines
+type Script struct {
+	Stmts []Stmt
+}
+
+// Stmt is a statement of the script, the node is one of
+// *Assignment, *FuncCall, *FuncDef, *Import, *Input and *Output.
+type Stmt struct {
+	// Line is the 1-based line of the statement
+	Line int
+	Node Node
+}
+
+// Parse parses the script without evaluating it, the empty
+// statements are skipped. The error is a *SyntaxError for the
+// statements can not be parsed.
+func Parse(rd io.Reader) (*Script, error) {
+	script := &Script{}
+	r := bufio.NewScanner(rd)
+	for ln := 1; r.Scan(); ln++ {
+		root, err := parseOneExpr(r.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln, err)
+		}
+		if root == nil {
+			continue // empty statement
+		}
+		script.Stmts = append(script.Stmts, Stmt{Line: ln, Node: root})
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// String prints the script back to source, one statement per line
+func (s *Script) String() string {
+	var b strings.Builder
+	for _, st := range s.Stmts {
+		b.WriteString(st.Node.String())
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// Visitor visits the nodes of Walk, if the w returned by Visit is
+// not nil, the children of the node are walked with w, followed by
+// a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the node in depth-first order like go/ast
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, c := range children(node) {
+		Walk(v, c)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the node in depth-first order, the children
+// of a node are skipped if f returns false, f is called with nil
+// after the children.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// children returns the child nodes in the order of the source
+func children(node Node) []Node {
+	switch n := node.(type) {
+	case *BinaryExpr:
+		return []Node{n.lhs, n.rhs}
+	case *UnaryExpr:
+		return []Node{n.expr}
+	case *ParenExpr:
+		return []Node{n.expr}
+	case *FuncCall:
+		return n.args
+	case *List:
+		return n.elements
+	case *Assignment:
+		return []Node{n.node}
+	case *FuncDef:
+		return []Node{n.body}
+	case *Input:
+		var ans []Node
+		for _, c := range []Node{n.def, n.lo, n.hi} {
+			if c != nil {
+				ans = append(ans, c)
+			}
+		}
+		return ans
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package calcu
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{src: "CO2 = activity_value*1.1E-04Gg/10^3m3;", expected: "CO2 = activity_value * 0.00011Gg/10^3m3"},
+		{src: `a = "1(10^3m3)" * 2;`, expected: "a = 1(10^3m3) * 2"},
+		{src: `fuel = "diesel";`, expected: `fuel = "diesel"`},
+		{src: "a = -(b + 2kg) * 5%;", expected: "a = -(b + 2kg) * 5%"},
+		{src: "a = b == c;", expected: "a = b == c"},
+		{src: "print(a, b);", expected: "print(a, b)"},
+		{src: "a = fuels.ncv(x, 1t/km);", expected: "a = fuels.ncv(x, 1t/km)"},
+		{src: "func f(a, b) = a * b;", expected: "func f(a, b) = a * b"},
+		{src: "func g() = 1kg;", expected: "func g() = 1kg"},
+		{src: `import "lib/fuels.calc";`, expected: `import "lib/fuels.calc"`},
+		{src: "input a: Volume default 0m3 range [0, 1e9];", expected: "input a: Volume default 0m3 range [0, 1000000000]"},
+		{src: "input a: Mass;", expected: "input a: Mass"},
+		{src: "input r: Unitless range [0, 1];", expected: "input r: Unitless range [0, 1]"},
+		{src: `output GHG in t as "Total" desc "All scopes";`, expected: `output GHG in t as "Total" desc "All scopes"`},
+		{src: `output v in "10^3m3";`, expected: "output v in (10^3m3)"},
+		{src: `output x in MWh as "kWh" desc "tonnes";`, expected: `output x in MWh as "kWh" desc "tonnes"`},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			script, err := Parse(strings.NewReader(c.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := script.Stmts[0].Node.String()
+			if got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+			// the printed source parses to the same
+			again, err := Parse(strings.NewReader(got + ";"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := again.Stmts[0].Node.String(); s != got {
+				t.Fatalf("expected %s, got %s", got, s)
+			}
+		})
+	}
+
+	script, err := Parse(strings.NewReader("a = 1kg;\n\nb = a * 2;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := script.String(); got != "a = 1kg;\nb = a * 2;\n" {
+		t.Fatalf("expected the script printed, got %q", got)
+	}
+	if line := script.Stmts[1].Line; line != 3 {
+		t.Fatalf("expected line 3, got %d", line)
+	}
+	if _, err := Parse(strings.NewReader("a = 1kg;\nb = ;")); err == nil || !strings.HasPrefix(err.Error(), "line 2: syntax error") {
+		t.Fatalf("expected syntax error of line 2, got %v", err)
+	}
+}
+
+type countVisitor map[NodeType]int
+
+func (v countVisitor) Visit(node Node) Visitor {
+	if node != nil {
+		v[node.Type()]++
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	script, err := Parse(strings.NewReader(`func f(x) = x * 2;
+CO2 = f(activity_value) + (b - 1kg) * 5%;
+input b: Mass default 2kg range [0kg, c];
+output CO2 in t;
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := countVisitor{}
+	for _, s := range script.Stmts {
+		Walk(v, s.Node)
+	}
+	expected := countVisitor{
+		NodeTypeFuncDef:    1,
+		NodeTypeAssignment: 1,
+		NodeTypeInput:      1,
+		NodeTypeOutput:     1,
+		NodeTypeBinaryExpr: 4,
+		NodeTypeUnaryExpr:  1,
+		NodeTypeParenExpr:  1,
+		NodeTypeFuncCall:   1,
+		NodeTypeVar:        4,
+		NodeTypeMV:         5,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %v, got %v", expected, v)
+	}
+
+	// the vars referenced, the func body is skipped
+	var vars []string
+	for _, s := range script.Stmts {
+		Inspect(s.Node, func(n Node) bool {
+			if v, ok := n.(*Variable); ok {
+				vars = append(vars, v.Name)
+			}
+			_, isDef := n.(*FuncDef)
+			return !isDef
+		})
+	}
+	if !reflect.DeepEqual(vars, []string{"activity_value", "b", "c"}) {
+		t.Fatalf("expected the vars referenced, got %v", vars)
+	}
+}
+
+func TestNodeAccessors(t *testing.T) {
+	script, err := Parse(strings.NewReader(`CO2 = f(a, 2kg) - b;
+func g(x, y) = x;
+import "lib/fuels.calc";
+input a: Volume default 1m3 range [0, 10];
+output CO2 in kg as "CO2" desc "Carbon dioxide";
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	asg := script.Stmts[0].Node.(*Assignment)
+	bin := asg.Expr().(*BinaryExpr)
+	fc := bin.LHS().(*FuncCall)
+	if asg.Name() != "CO2" || bin.Op != OpSub || bin.RHS().(*Variable).Name != "b" ||
+		fc.Name() != "f" || len(fc.Args()) != 2 || fc.Pos() != 7 || fc.Args()[1].(*MeasureValue).Unit() != "kg" {
+		t.Fatalf("unexpected assignment %s", asg)
+	}
+	def := script.Stmts[1].Node.(*FuncDef)
+	if def.Name() != "g" || !reflect.DeepEqual(def.Params(), []string{"x", "y"}) || def.Body().String() != "x" {
+		t.Fatalf("unexpected func def %s", def)
+	}
+	if imp := script.Stmts[2].Node.(*Import); imp.Path() != "lib/fuels.calc" {
+		t.Fatalf("unexpected import %s", imp)
+	}
+	in := script.Stmts[3].Node.(*Input)
+	lo, hi := in.Range()
+	if in.Name() != "a" || in.Dimension() != DimVolume || in.Default().String() != "1m3" || lo.String() != "0" || hi.String() != "10" {
+		t.Fatalf("unexpected input %s", in)
+	}
+	out := script.Stmts[4].Node.(*Output)
+	if out.Name() != "CO2" || out.Unit() != "kg" || out.Label() != "CO2" || out.Description() != "Carbon dioxide" {
+		t.Fatalf("unexpected output %s", out)
+	}
+}
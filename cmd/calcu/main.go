@@ -0,0 +1,418 @@
+// Command calcu runs calcu scripts from the command line.
+//
+// Usage:
+//
+//	calcu run [--var name=value]... [--vars vars.json] [--format text|json] [--locale de] script.calc
+//	calcu check [--var name=value]... [--vars vars.json] script.calc
+//	calcu convert value unit
+//	calcu repl [--var name=value]... [--vars vars.json]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/maxnilz/calcu"
+)
+
+// exit codes
+const (
+	exitOK = iota
+	exitUsage
+	exitParse
+	exitEval
+	exitIO
+)
+
+const usage = `usage:
+  calcu run [--var name=value]... [--vars vars.json] [--format text|json] [--locale de] script.calc
+  calcu check [--var name=value]... [--vars vars.json] script.calc
+  calcu convert value unit
+  calcu repl [--var name=value]... [--vars vars.json]
+
+commands:
+  run      evaluate the script and print the vars saved by print
+  check    parse and evaluate the script to find the dimension errors,
+           the inputs not given are of placeholders of the dimension
+  convert  convert the measure value to the unit, e.g., calcu convert 5Gg t
+  repl     evaluate the statements interactively, see :help
+
+the script is read from stdin if it is -, imports are resolved
+from the directory of the script.
+
+exit codes:
+  1  usage error
+  2  parse error
+  3  evaluation error
+  4  I/O error
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "run":
+		return runScript(args, stdin, stdout, stderr)
+	case "check":
+		return checkScript(args, stdin, stdout, stderr)
+	case "convert":
+		return convert(args, stdout, stderr)
+	case "repl":
+		return runRepl(args, stdin, stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", cmd, usage)
+		return exitUsage
+	}
+}
+
+// varsFlag collects the repeated --var name=value flags
+type varsFlag map[string]string
+
+func (v varsFlag) String() string {
+	return fmt.Sprint(map[string]string(v))
+}
+
+func (v varsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expect name=value, got %q", s)
+	}
+	v[name] = value
+	return nil
+}
+
+type options struct {
+	vars     varsFlag
+	varsFile string
+	format   string
+	locale   string
+}
+
+func newFlagSet(name string, stderr io.Writer, opts *options) *flag.FlagSet {
+	opts.vars = make(varsFlag)
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	fset.Var(opts.vars, "var", "input var as name=value, repeatable")
+	fset.StringVar(&opts.varsFile, "vars", "", "JSON file of input vars")
+	return fset
+}
+
+// parseArgs parses the flags interspersed with the positional args
+func parseArgs(fset *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return nil, err
+		}
+		if fset.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+}
+
+// loadVars merges the vars of --vars file and --var flags,
+// the --var flags take precedence.
+func loadVars(opts *options) (map[string]string, error) {
+	vars := make(map[string]string)
+	if opts.varsFile != "" {
+		data, err := os.ReadFile(opts.varsFile)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			return nil, fmt.Errorf("parse %s: %v", opts.varsFile, err)
+		}
+		for k, v := range m {
+			switch a := v.(type) {
+			case string:
+				vars[k] = a
+			case json.Number:
+				vars[k] = a.String()
+			default:
+				return nil, fmt.Errorf("parse %s: expect string or number of var %s, got %T", opts.varsFile, k, v)
+			}
+		}
+	}
+	for k, v := range opts.vars {
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// readScript reads the script and returns the directory the
+// imports are resolved from.
+func readScript(name string, stdin io.Reader) ([]byte, string, error) {
+	if name == "-" {
+		data, err := io.ReadAll(stdin)
+		return data, ".", err
+	}
+	data, err := os.ReadFile(name)
+	return data, filepath.Dir(name), err
+}
+
+func exitCode(err error) int {
+	var se *calcu.SyntaxError
+	if errors.As(err, &se) {
+		return exitParse
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return exitIO
+	}
+	return exitEval
+}
+
+func runScript(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	fset := newFlagSet("run", stderr, &opts)
+	fset.StringVar(&opts.format, "format", "text", "output format, text or json")
+	fset.StringVar(&opts.locale, "locale", "", "locale of the input vars and the outputs, en, de, fr or ch")
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintf(stderr, "expect one script, got %d\n%s", len(positional), usage)
+		return exitUsage
+	}
+	if opts.format != "text" && opts.format != "json" {
+		fmt.Fprintf(stderr, "unknown format %q\n", opts.format)
+		return exitUsage
+	}
+	var fns []interface{}
+	format := (*calcu.MeasureValue).String
+	if opts.locale != "" {
+		loc, ok := calcu.LookupLocale(opts.locale)
+		if !ok {
+			fmt.Fprintf(stderr, "unknown locale %q\n", opts.locale)
+			return exitUsage
+		}
+		fns = append(fns, calcu.WithLocale(loc))
+		format = loc.Format
+	}
+	vars, err := loadVars(&opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+	script, dir, err := readScript(positional[0], stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+	intrp, err := calcu.NewInterpreter(vars, append(fns, calcu.WithFS(os.DirFS(dir)))...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitEval
+	}
+	outvars, err := intrp.Interpret(bytes.NewReader(script))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCode(err)
+	}
+
+	outs := make(map[string]string)
+	for k, mv := range outvars {
+		outs[k] = format(mv)
+	}
+	for k, s := range intrp.OutStrings() {
+		outs[k] = s
+	}
+	if err := writeOutputs(stdout, outs, opts.format); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+	return exitOK
+}
+
+func writeOutputs(w io.Writer, outs map[string]string, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(outs)
+	}
+	names := make([]string, 0, len(outs))
+	for k := range outs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", k, outs[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkScript(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	fset := newFlagSet("check", stderr, &opts)
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintf(stderr, "expect one script, got %d\n%s", len(positional), usage)
+		return exitUsage
+	}
+	vars, err := loadVars(&opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+	script, dir, err := readScript(positional[0], stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+	parsed, err := calcu.Parse(bytes.NewReader(script))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCode(err)
+	}
+	// the dimension errors are only found by evaluation,
+	// the inputs not given are of the placeholders.
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	if err := placeholders(parsed, vars); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	intrp, err := calcu.NewInterpreter(vars, calcu.WithFS(os.DirFS(dir)))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitEval
+	}
+	if _, err := intrp.Interpret(bytes.NewReader(script)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCode(err)
+	}
+	fmt.Fprintln(stdout, "ok")
+	return exitOK
+}
+
+// placeholders sets the inputs declared without a default and not
+// given to a value of the dimension, i.e., the lower bound of the
+// range or 1 of the SI unit. The vars neither given nor declared
+// nor assigned before use are reported, the script can not be
+// type-checked without them.
+func placeholders(script *calcu.Script, vars map[string]string) error {
+	defined := make(map[string]bool)
+	for k := range vars {
+		defined[k] = true
+	}
+	for _, st := range script.Stmts {
+		in, ok := st.Node.(*calcu.Input)
+		if !ok {
+			continue
+		}
+		defined[in.Name()] = true
+		if _, given := vars[in.Name()]; given || in.Default() != nil {
+			continue
+		}
+		v, err := placeholder(in)
+		if err != nil {
+			return err
+		}
+		vars[in.Name()] = v
+	}
+	var missing []string
+	for _, st := range script.Stmts {
+		calcu.Inspect(st.Node, func(n calcu.Node) bool {
+			switch n := n.(type) {
+			case *calcu.FuncDef:
+				// the params are the only local vars
+				return false
+			case *calcu.Variable:
+				// the imported vars are checked by evaluation
+				if !defined[n.Name] && !strings.Contains(n.Name, ".") {
+					defined[n.Name] = true
+					missing = append(missing, n.Name)
+				}
+			}
+			return true
+		})
+		if a, ok := st.Node.(*calcu.Assignment); ok {
+			defined[a.Name()] = true
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cannot type-check without vars: %s not given, pass them by --var or declare them by input",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// placeholder returns a value of the dimension of the input
+func placeholder(in *calcu.Input) (string, error) {
+	if in.IsUnitless() {
+		if lo, _ := in.Range(); lo != nil {
+			return lo.String(), nil
+		}
+		return "1", nil
+	}
+	units, _ := calcu.StdUm.ListMetaUnitsByDims(in.Dimension())
+	if len(units) == 0 {
+		return "", fmt.Errorf("cannot type-check without vars: no unit of input %s", in.Name())
+	}
+	si := units[0].SiName()
+	for _, u := range units {
+		if u.Name() == u.SiName() {
+			si = u.Name()
+			break
+		}
+	}
+	// a unitless lower bound is of the SI unit
+	if lo, _ := in.Range(); lo != nil {
+		if mv, ok := lo.(*calcu.MeasureValue); ok && mv.IsUnitless() {
+			return mv.Value().String() + si, nil
+		} else if ok {
+			return mv.String(), nil
+		}
+	}
+	return "1" + si, nil
+}
+
+func convert(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintf(stderr, "expect value and unit, got %d args\n%s", len(args), usage)
+		return exitUsage
+	}
+	mv, err := calcu.NewMeasureValueFromString(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitParse
+	}
+	ans, err := mv.To(args[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitEval
+	}
+	fmt.Fprintln(stdout, ans)
+	return exitOK
+}
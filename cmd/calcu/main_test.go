@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "factors.calc", "co2 = 1.1E-04Gg/10^3m3;\n")
+	script := writeFile(t, dir, "main.calc", `
+import "factors.calc";
+CO2 = activity_value * factors.co2;
+print(CO2, fuel);
+`)
+	bad := writeFile(t, dir, "bad.calc", "CO2 = activity_value *;\n")
+	dim := writeFile(t, dir, "dim.calc", "CO2 = activity_value + 1kg;\n")
+	mixed := writeFile(t, dir, "mixed.calc", "a = 1kg + 1m3;\n")
+	inputs := writeFile(t, dir, "inputs.calc", "input a: Volume range [1, 10];\ninput b: Mass default 1kg;\nc = a * 2 + b;\n")
+	typed := writeFile(t, dir, "typed.calc", "input a: Volume;\ninput b: Mass default 1kg;\ninput r: Unitless range [0, 1];\nc = a * 2 + 1m3;\nd = b * 2 * r;\nprint(c, d);\n")
+	varsFile := writeFile(t, dir, "vars.json", `{"activity_value": "2(10^3m3)", "fuel": "diesel"}`)
+
+	cases := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+	}{
+		{args: []string{"run", script, "--var", "activity_value=1(10^3m3)", "--var", "fuel=diesel"}, code: exitOK,
+			stdout: "CO2 = 110kg\nfuel = diesel\n"},
+		{args: []string{"run", "--vars", varsFile, script}, code: exitOK,
+			stdout: "CO2 = 220kg\nfuel = diesel\n"},
+		{args: []string{"run", "--vars", varsFile, "--var", "activity_value=1(10^3m3)", "--format", "json", script}, code: exitOK,
+			stdout: "{\n  \"CO2\": \"110kg\",\n  \"fuel\": \"diesel\"\n}\n"},
+		{args: []string{"run", "-", "--var", "a=1kg"}, stdin: "b = a * 2;\nprint(b);\n", code: exitOK, stdout: "b = 2kg\n"},
+		{args: []string{"run", "-", "--var", "a=1.234,5 t", "--locale", "de"}, stdin: "b = a * 2;\nprint(b);\n", code: exitOK,
+			stdout: "b = 2.469 t\n"},
+		{args: []string{"run", "-", "--locale", "xx"}, stdin: "b = 1;\n", code: exitUsage},
+		{args: []string{"run", bad}, code: exitParse},
+		{args: []string{"run", dim, "--var", "activity_value=1m3"}, code: exitEval},
+		{args: []string{"run", filepath.Join(dir, "missing.calc")}, code: exitIO},
+		{args: []string{"run", script, "--vars", filepath.Join(dir, "missing.json")}, code: exitIO},
+		{args: []string{"run", script, "--format", "xml"}, code: exitUsage},
+		{args: []string{"run"}, code: exitUsage},
+		{args: []string{"check", script, "--vars", varsFile}, code: exitOK, stdout: "ok\n"},
+		{args: []string{"check", script}, code: exitUsage},
+		{args: []string{"check", bad}, code: exitParse},
+		{args: []string{"check", dim}, code: exitUsage},
+		{args: []string{"check", mixed}, code: exitEval},
+		{args: []string{"check", inputs}, code: exitEval},
+		{args: []string{"check", typed}, code: exitOK, stdout: "ok\n"},
+		{args: []string{"check", dim, "--var", "activity_value=1m3"}, code: exitEval},
+		{args: []string{"convert", "5Gg", "t"}, code: exitOK, stdout: "5000t\n"},
+		{args: []string{"convert", "5Gg", "m3"}, code: exitEval},
+		{args: []string{"convert", "5xx", "t"}, code: exitParse},
+		{args: []string{"convert", "5Gg"}, code: exitUsage},
+		{args: []string{"unknown"}, code: exitUsage},
+		{args: nil, code: exitUsage},
+	}
+	for _, c := range cases {
+		t.Run(strings.Join(c.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+			if code != c.code {
+				t.Fatalf("expected exit code %d, got %d: %s", c.code, code, stderr.String())
+			}
+			if c.stdout != "" && stdout.String() != c.stdout {
+				t.Fatalf("expected stdout %q, got %q", c.stdout, stdout.String())
+			}
+		})
+	}
+}
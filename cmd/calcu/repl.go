@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/maxnilz/calcu"
+	"golang.org/x/term"
+)
+
+const replHelp = `statements are evaluated as they are entered, the trailing ; is optional,
+an expression alone prints its value.
+
+meta commands:
+  :vars         list the vars
+  :units [dim]  list the dimensions, or the units of the dimension, e.g., :units Mass
+  :funcs        list the funcs
+  :trace x      show how the var x is derived
+  :reset        discard the vars and funcs defined in the session
+  :help         show this help
+  :quit         exit the repl
+`
+
+// lineReader reads the lines entered
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+type scanReader struct {
+	*bufio.Scanner
+}
+
+func (r scanReader) ReadLine() (string, error) {
+	if !r.Scan() {
+		if err := r.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.Text(), nil
+}
+
+// session keeps one interpreter across the lines
+type session struct {
+	vars  map[string]string
+	intrp *calcu.Interpreter
+	// defs are the exprs assigned to the vars, for tracing
+	defs map[string]string
+	out  io.Writer
+}
+
+func newSession(vars map[string]string, out io.Writer) (*session, error) {
+	s := &session{vars: vars, out: out}
+	if err := s.reset(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *session) reset() error {
+	intrp, err := calcu.NewInterpreter(s.vars, calcu.WithFS(os.DirFS(".")))
+	if err != nil {
+		return err
+	}
+	s.intrp = intrp
+	s.defs = make(map[string]string)
+	return nil
+}
+
+var (
+	assignRe = regexp.MustCompile(`^\s*([_a-zA-Z][_a-zA-Z0-9]*)\s*=([^=].*)$`)
+	identRe  = regexp.MustCompile(`[_a-zA-Z][_a-zA-Z0-9.]*`)
+	quotedRe = regexp.MustCompile(`"[^"]*"`)
+)
+
+// exec evaluates the line, it reports whether to quit.
+func (s *session) exec(line string) (quit bool) {
+	// a panic fails the line only, not the session
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(s.out, "panic:", r)
+			quit = false
+		}
+	}()
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	if strings.HasPrefix(line, ":") {
+		return s.meta(line)
+	}
+	stmt := line
+	if !strings.HasSuffix(stmt, ";") {
+		stmt += ";"
+	}
+	// an expression alone is evaluated as an assignment
+	// to a throwaway var to print its value.
+	if calcu.Check(strings.NewReader(stmt)) != nil {
+		expr := "_ = " + stmt
+		if calcu.Check(strings.NewReader(expr)) == nil {
+			stmt = expr
+		}
+	}
+	if _, err := s.intrp.Interpret(strings.NewReader(stmt)); err != nil {
+		fmt.Fprintln(s.out, err)
+		return false
+	}
+	if m := assignRe.FindStringSubmatch(stmt); m != nil {
+		name := m[1]
+		if name != "_" {
+			s.defs[name] = strings.TrimSuffix(strings.TrimSpace(m[2]), ";")
+		}
+		if v, ok := s.value(name); ok {
+			if name == "_" {
+				fmt.Fprintln(s.out, v)
+			} else {
+				fmt.Fprintf(s.out, "%s = %s\n", name, v)
+			}
+		}
+	}
+	return false
+}
+
+func (s *session) value(name string) (string, bool) {
+	if mv, ok := s.intrp.Vars()[name]; ok {
+		return mv.String(), true
+	}
+	if str, ok := s.intrp.StrVars()[name]; ok {
+		return fmt.Sprintf("%q", str), true
+	}
+	return "", false
+}
+
+func (s *session) meta(line string) bool {
+	fields := strings.Fields(line)
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case ":quit", ":q":
+		return true
+	case ":help":
+		fmt.Fprint(s.out, replHelp)
+	case ":vars":
+		for _, name := range s.names() {
+			v, _ := s.value(name)
+			fmt.Fprintf(s.out, "%s = %s\n", name, v)
+		}
+	case ":units":
+		if len(args) == 0 {
+			for _, d := range calcu.Dimensions() {
+				fmt.Fprintln(s.out, d)
+			}
+			break
+		}
+		dim := calcu.DimensionFromString(args[0])
+		if dim == calcu.DimInvalid {
+			fmt.Fprintf(s.out, "unknown dimension %s\n", args[0])
+			break
+		}
+		units, _ := calcu.StdUm.ListMetaUnitsByDims(dim)
+		for _, u := range units {
+			fmt.Fprintf(s.out, "%s\t%s\n", u.Name(), u.Label())
+		}
+	case ":funcs":
+		for _, fi := range s.intrp.Funcs() {
+			if fi.Doc != "" {
+				fmt.Fprintf(s.out, "%s\t%s\n", fi.Signature(), fi.Doc)
+				continue
+			}
+			fmt.Fprintln(s.out, fi.Signature())
+		}
+	case ":trace":
+		if len(args) != 1 {
+			fmt.Fprintln(s.out, "usage: :trace x")
+			break
+		}
+		if _, ok := s.value(args[0]); !ok {
+			fmt.Fprintf(s.out, "undefined var %s\n", args[0])
+			break
+		}
+		s.trace(args[0], "", make(map[string]bool))
+	case ":reset":
+		if err := s.reset(); err != nil {
+			fmt.Fprintln(s.out, err)
+		}
+	default:
+		fmt.Fprintf(s.out, "unknown command %s, see :help\n", cmd)
+	}
+	return false
+}
+
+// names lists the var names ordered
+func (s *session) names() []string {
+	var names []string
+	for name := range s.intrp.Vars() {
+		names = append(names, name)
+	}
+	for name := range s.intrp.StrVars() {
+		names = append(names, name)
+	}
+	// the throwaway var of the exprs alone is hidden
+	for k, name := range names {
+		if name == "_" {
+			names = append(names[:k], names[k+1:]...)
+			break
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// trace prints the var with the expr it is assigned,
+// followed by the vars the expr refers to.
+func (s *session) trace(name, indent string, seen map[string]bool) {
+	v, _ := s.value(name)
+	def, ok := s.defs[name]
+	if !ok {
+		fmt.Fprintf(s.out, "%s%s = %s (input)\n", indent, name, v)
+		return
+	}
+	if def == v {
+		fmt.Fprintf(s.out, "%s%s = %s\n", indent, name, v)
+	} else {
+		fmt.Fprintf(s.out, "%s%s = %s = %s\n", indent, name, def, v)
+	}
+	if seen[name] {
+		return
+	}
+	seen[name] = true
+	refs := make(map[string]bool)
+	for _, ident := range identRe.FindAllString(quotedRe.ReplaceAllString(def, ""), -1) {
+		if _, ok := s.value(ident); !ok || refs[ident] || ident == name || ident == "_" {
+			continue
+		}
+		refs[ident] = true
+		s.trace(ident, indent+"  ", seen)
+	}
+}
+
+// complete returns the vars, funcs and units starting with prefix
+func (s *session) complete(prefix string) []string {
+	var ans []string
+	add := func(name string) {
+		if strings.HasPrefix(name, prefix) {
+			ans = append(ans, name)
+		}
+	}
+	for _, name := range s.names() {
+		add(name)
+	}
+	for _, fi := range s.intrp.Funcs() {
+		add(fi.Name)
+	}
+	units, _ := calcu.StdUm.ListMetaUnitsByDims(calcu.Dimensions()...)
+	for _, u := range units {
+		add(u.Name())
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// autoComplete completes the word before the cursor on tab
+// with the common prefix of the candidates.
+func (s *session) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	start := pos
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	prefix := line[start:pos]
+	if prefix == "" {
+		return "", 0, false
+	}
+	candidates := s.complete(prefix)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	return line[:start] + common + line[pos:], start + len(common), true
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '^' || (c >= '0' && c <= '9') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func runRepl(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	fset := newFlagSet("repl", stderr, &opts)
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(stderr, "unexpected args %v\n%s", positional, usage)
+		return exitUsage
+	}
+	vars, err := loadVars(&opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIO
+	}
+
+	var rd lineReader
+	out := stdout
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitIO
+		}
+		defer term.Restore(int(f.Fd()), state)
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{stdin, stdout}, "calcu> ")
+		rd, out = t, t
+		s, err := newSession(vars, out)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitEval
+		}
+		t.AutoCompleteCallback = s.autoComplete
+		return loop(s, rd)
+	}
+
+	rd = scanReader{bufio.NewScanner(stdin)}
+	s, err := newSession(vars, out)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitEval
+	}
+	return loop(s, rd)
+}
+
+func loop(s *session, rd lineReader) int {
+	for {
+		line, err := rd.ReadLine()
+		if err == io.EOF {
+			return exitOK
+		}
+		if err != nil {
+			fmt.Fprintln(s.out, err)
+			return exitIO
+		}
+		if quit := s.exec(line); quit {
+			return exitOK
+		}
+	}
+}
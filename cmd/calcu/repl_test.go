@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/maxnilz/calcu"
+)
+
+func TestRepl(t *testing.T) {
+	input := `
+CO2 = activity_value * factor
+factor2 = factor * 2;
+CO2b = activity_value * factor2
+CO2
+fuel + " oil"
+:trace CO2b
+:vars
+CO2 = CO2 +
+:units Mass
+:units Foo
+:reset
+:vars
+:bogus
+:quit
+ignored = 1
+`
+	var stdout, stderr bytes.Buffer
+	args := []string{"repl", "--var", "activity_value=1(10^3m3)", "--var", "factor=1.1E-04Gg/10^3m3", "--var", "fuel=diesel"}
+	code := run(args, strings.NewReader(input), &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected := `CO2 = 110kg
+factor2 = 0.00022Gg/10^3m3
+CO2b = 220kg
+110kg
+"diesel oil"
+CO2b = activity_value * factor2 = 220kg
+  activity_value = 1(10^3m3) (input)
+  factor2 = factor * 2 = 0.00022Gg/10^3m3
+    factor = 0.00011Gg/10^3m3 (input)
+CO2 = 110kg
+CO2b = 220kg
+activity_value = 1(10^3m3)
+factor = 0.00011Gg/10^3m3
+factor2 = 0.00022Gg/10^3m3
+fuel = "diesel"
+line 1: syntax error: unexpected ';'
+`
+	got := stdout.String()
+	if !strings.HasPrefix(got, expected) {
+		t.Fatalf("expected output starts with:\n%s\ngot:\n%s", expected, got)
+	}
+	rest := got[len(expected):]
+	for _, s := range []string{"kg\tKilogram", "unknown dimension Foo", "activity_value = 1(10^3m3)\nfactor = 0.00011Gg/10^3m3\nfuel = \"diesel\"\n", "unknown command :bogus"} {
+		if !strings.Contains(rest, s) {
+			t.Fatalf("expected output contains %q, got:\n%s", s, rest)
+		}
+	}
+	if strings.Contains(rest, "CO2") || strings.Contains(rest, "ignored") {
+		t.Fatalf("expected vars reset and input after :quit ignored, got:\n%s", rest)
+	}
+}
+
+func fPanic(a *calcu.MeasureValue) *calcu.MeasureValue {
+	panic("boom")
+}
+
+func TestReplErrors(t *testing.T) {
+	var out bytes.Buffer
+	s, err := newSession(map[string]string{"a": "2kg"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.intrp, err = calcu.NewInterpreter(s.vars, fPanic); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"b = a / 0", "c = fPanic(a)", "d = a * 2"} {
+		if s.exec(line) {
+			t.Fatalf("expected no quit of %s", line)
+		}
+	}
+	expected := "line 1: (2kg)/(0): division by zero\nline 1: call func fPanic at col 5 failed: boom\nd = 4kg\n"
+	if got := out.String(); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestReplAutoComplete(t *testing.T) {
+	var out bytes.Buffer
+	s, err := newSession(map[string]string{"activity_value": "1kg", "activity_unit": "kg"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		line     string
+		pos      int
+		expected string
+		ok       bool
+	}{
+		{line: "a = act", pos: 7, expected: "a = activity_", ok: true},
+		{line: "a = activity_v * 2", pos: 14, expected: "a = activity_value * 2", ok: true},
+		{line: "a = 1Kilo", pos: 9, ok: false},
+		{line: "a = 1Gg + pri", pos: 13, expected: "a = 1Gg + print", ok: true},
+		{line: "a = ", pos: 4, ok: false},
+	}
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			line, _, ok := s.autoComplete(c.line, c.pos, '\t')
+			if ok != c.ok || line != c.expected {
+				t.Fatalf("expected %q, %v, got %q, %v", c.expected, c.ok, line, ok)
+			}
+		})
+	}
+}
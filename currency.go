@@ -0,0 +1,289 @@
+package calcu
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RateProvider resolves the exchange rates of the currencies
+type RateProvider interface {
+	// Rate returns the amount of the currency to per
+	// one of the currency from on the date.
+	Rate(from, to string, date time.Time) (decimal.Decimal, error)
+}
+
+// PriceIndex resolves the price level of the currency
+// by year, e.g., the consumer price index.
+type PriceIndex interface {
+	Index(currency string, year int) (decimal.Decimal, error)
+}
+
+// WithRates sets the exchange rates of the exchange func
+func WithRates(p RateProvider) Option {
+	return func(i *Interpreter) {
+		i.rates = p
+	}
+}
+
+// WithPriceIndex sets the price index of the inflate func
+func WithPriceIndex(p PriceIndex) Option {
+	return func(i *Interpreter) {
+		i.prices = p
+	}
+}
+
+const dateLayout = "2006-01-02"
+
+// currencyOf returns the currency unit of v and how it's placed, i.e., v
+// is of the currency, e.g., USD, or a compound unit with the currency as
+// numerator, e.g., USD/kg, or as denominator, e.g., kg/USD.
+func currencyOf(v *MeasureValue) (cur *MetaUnit, num bool, den bool, err error) {
+	if v == nil {
+		return nil, false, false, fmt.Errorf("found undefined value")
+	}
+	if !v.unitless {
+		u, _ := v.um.GetByName(v.unit)
+		switch u := u.(type) {
+		case *MetaUnit:
+			if u.dimension == DimCurrency {
+				return u, false, false, nil
+			}
+		case *CompoundUnit:
+			isNum, isDen := u.Numerator.dimension == DimCurrency, u.Denominator.dimension == DimCurrency
+			if isNum && !isDen {
+				return u.Numerator, true, false, nil
+			}
+			if isDen && !isNum {
+				return u.Denominator, false, true, nil
+			}
+		}
+	}
+	return nil, false, false, fmt.Errorf("expect currency value, e.g., 100USD or 0.5kg/USD, got %s", v)
+}
+
+// Exchange converts the currency of v to the currency to by the rate
+// on the date, v might be of a compound unit, e.g., 0.5kg/USD to kg/EUR.
+func Exchange(p RateProvider, v *MeasureValue, to string, date time.Time) (*MeasureValue, error) {
+	if p == nil {
+		return nil, fmt.Errorf("no exchange rates, see WithRates")
+	}
+	if v == nil {
+		return nil, fmt.Errorf("found undefined value")
+	}
+	tu, ok := v.um.GetByName(to)
+	if mu, isMeta := tu.(*MetaUnit); !ok || !isMeta || mu.dimension != DimCurrency {
+		return nil, fmt.Errorf("expect currency, e.g., USD, got %s", to)
+	}
+	to = tu.Name()
+	cur, num, den, err := currencyOf(v)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := p.Rate(cur.name, to, date)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := v.um.GetByName(v.unit)
+	switch {
+	case num:
+		cu := u.(*CompoundUnit)
+		return &MeasureValue{um: v.um, unit: to + "/" + cu.Denominator.name, value: v.value.Mul(rate)}, nil
+	case den:
+		cu := u.(*CompoundUnit)
+		return &MeasureValue{um: v.um, unit: cu.Numerator.name + "/" + to, value: v.value.Div(rate)}, nil
+	default:
+		return &MeasureValue{um: v.um, unit: to, value: v.value.Mul(rate)}, nil
+	}
+}
+
+// Inflate adjusts the currency of v from the price level of the year
+// from to the year to, e.g., the spend of 2023 to the base year 2015
+// of the spend-based factors.
+func Inflate(p PriceIndex, v *MeasureValue, from, to int) (*MeasureValue, error) {
+	if p == nil {
+		return nil, fmt.Errorf("no price index, see WithPriceIndex")
+	}
+	cur, _, den, err := currencyOf(v)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := p.Index(cur.name, from)
+	if err != nil {
+		return nil, err
+	}
+	ti, err := p.Index(cur.name, to)
+	if err != nil {
+		return nil, err
+	}
+	if den {
+		// the value per currency falls as the prices rise
+		return v.withValue(v.value.Mul(fi).Div(ti)), nil
+	}
+	return v.withValue(v.value.Mul(ti).Div(fi)), nil
+}
+
+type datedRate struct {
+	date time.Time
+	rate decimal.Decimal
+}
+
+// CSVRates is a RateProvider of the rates loaded from CSV, the
+// rate on a date is the latest one on or before the date. The
+// inverse rates and the rates via a common currency are derived.
+type CSVRates struct {
+	m map[[2]string][]datedRate
+	// currencies are sorted to pick the common currency in order
+	currencies []string
+}
+
+// LoadCSVRates loads the rates from CSV of the header date,from,to,rate,
+// e.g., 2023-06-30,EUR,USD,1.0866 is 1.0866USD per EUR.
+func LoadCSVRates(r io.Reader) (*CSVRates, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	c := &CSVRates{m: make(map[[2]string][]datedRate)}
+	for k, record := range records {
+		if k == 0 {
+			continue // skip header row
+		}
+		if len(record) != 4 {
+			return nil, fmt.Errorf("line %d: expect date,from,to,rate, got %v", k+1, record)
+		}
+		date, err := time.Parse(dateLayout, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", k+1, err)
+		}
+		rate, err := decimal.NewFromString(record[3])
+		if err != nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %s", k+1, record[3])
+		}
+		key := [2]string{record[1], record[2]}
+		c.m[key] = append(c.m[key], datedRate{date: date, rate: rate})
+	}
+	seen := make(map[string]bool)
+	for key, rates := range c.m {
+		sort.Slice(rates, func(i, j int) bool {
+			return rates[i].date.Before(rates[j].date)
+		})
+		for _, cur := range key {
+			if !seen[cur] {
+				seen[cur] = true
+				c.currencies = append(c.currencies, cur)
+			}
+		}
+	}
+	sort.Strings(c.currencies)
+	return c, nil
+}
+
+func (c *CSVRates) Rate(from, to string, date time.Time) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	if rate, ok := c.rate(from, to, date); ok {
+		return rate, nil
+	}
+	// via a common currency, the first one in alphabetical
+	// order is picked if there are more.
+	for _, via := range c.currencies {
+		if via == from || via == to {
+			continue
+		}
+		r1, ok1 := c.rate(from, via, date)
+		r2, ok2 := c.rate(via, to, date)
+		if ok1 && ok2 {
+			return r1.Mul(r2), nil
+		}
+	}
+	return decimal.Zero, fmt.Errorf("no exchange rate of %s to %s on %s", from, to, date.Format(dateLayout))
+}
+
+// rate looks up the direct or the inverse rate
+func (c *CSVRates) rate(from, to string, date time.Time) (decimal.Decimal, bool) {
+	if rate, ok := latest(c.m[[2]string{from, to}], date); ok {
+		return rate, true
+	}
+	if rate, ok := latest(c.m[[2]string{to, from}], date); ok {
+		return decimal.NewFromInt(1).Div(rate), true
+	}
+	return decimal.Zero, false
+}
+
+func latest(rates []datedRate, date time.Time) (decimal.Decimal, bool) {
+	k := sort.Search(len(rates), func(i int) bool {
+		return rates[i].date.After(date)
+	})
+	if k == 0 {
+		return decimal.Zero, false
+	}
+	return rates[k-1].rate, true
+}
+
+// CSVPriceIndex is a PriceIndex loaded from CSV
+type CSVPriceIndex struct {
+	m map[string]map[int]decimal.Decimal
+}
+
+// LoadCSVPriceIndex loads the price index from CSV of the header
+// year,currency,index, e.g., 2015,USD,237.017.
+func LoadCSVPriceIndex(r io.Reader) (*CSVPriceIndex, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	c := &CSVPriceIndex{m: make(map[string]map[int]decimal.Decimal)}
+	for k, record := range records {
+		if k == 0 {
+			continue // skip header row
+		}
+		if len(record) != 3 {
+			return nil, fmt.Errorf("line %d: expect year,currency,index, got %v", k+1, record)
+		}
+		year, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid year %s", k+1, record[0])
+		}
+		index, err := decimal.NewFromString(record[2])
+		if err != nil || index.Sign() <= 0 {
+			return nil, fmt.Errorf("line %d: invalid index %s", k+1, record[2])
+		}
+		if c.m[record[1]] == nil {
+			c.m[record[1]] = make(map[int]decimal.Decimal)
+		}
+		c.m[record[1]][year] = index
+	}
+	return c, nil
+}
+
+func (c *CSVPriceIndex) Index(currency string, year int) (decimal.Decimal, error) {
+	index, ok := c.m[currency][year]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no price index of %s in %d", currency, year)
+	}
+	return index, nil
+}
+
+// exchange is the kernel func of Exchange, the date is formatted as 2006-01-02
+func (i *Interpreter) exchange(v *MeasureValue, to string, date string) (*MeasureValue, error) {
+	d, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("expect date as %s, got %s", dateLayout, date)
+	}
+	return Exchange(i.rates, v, to, d)
+}
+
+// inflate is the kernel func of Inflate
+func (i *Interpreter) inflate(v *MeasureValue, from, to decimal.Decimal) (*MeasureValue, error) {
+	if !from.IsInteger() || !to.IsInteger() {
+		return nil, fmt.Errorf("expect years, got %s and %s", from, to)
+	}
+	return Inflate(i.prices, v, int(from.IntPart()), int(to.IntPart()))
+}
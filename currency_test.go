@@ -0,0 +1,152 @@
+package calcu
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRates = `date,from,to,rate
+2023-01-02,EUR,USD,1.07
+2023-06-30,EUR,USD,1.0866
+2023-06-30,USD,CNY,7.25
+`
+
+const testPrices = `year,currency,index
+2015,USD,100
+2023,USD,125
+`
+
+func TestCSVRates(t *testing.T) {
+	rates, err := LoadCSVRates(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		from, to string
+		date     string
+		expected string
+	}{
+		{from: "EUR", to: "USD", date: "2023-03-01", expected: "1.07"},
+		{from: "EUR", to: "USD", date: "2023-07-01", expected: "1.0866"},
+		{from: "USD", to: "EUR", date: "2023-01-02", expected: "0.9345794392523364"},
+		{from: "EUR", to: "CNY", date: "2023-06-30", expected: "7.87785"},
+		{from: "USD", to: "USD", date: "2000-01-01", expected: "1"},
+		{from: "EUR", to: "USD", date: "2022-12-31"},
+		{from: "EUR", to: "JPY", date: "2023-06-30"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			date, _ := time.Parse(dateLayout, c.date)
+			got, err := rates.Rate(c.from, c.to, date)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+
+	// the common currency is picked in alphabetical order
+	pivots, err := LoadCSVRates(strings.NewReader(`date,from,to,rate
+2023-06-30,GBP,USD,1.27
+2023-06-30,USD,JPY,144
+2023-06-30,GBP,CHF,1.14
+2023-06-30,CHF,JPY,161
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, _ := time.Parse(dateLayout, "2023-06-30")
+	for k := 0; k < 10; k++ {
+		if got, err := pivots.Rate("GBP", "JPY", date); err != nil || got.String() != "183.54" {
+			t.Fatalf("expected 183.54 via CHF, got %v, %v", got, err)
+		}
+	}
+
+	for _, bad := range []string{
+		"date,from,to,rate\n2023-13-01,EUR,USD,1",
+		"date,from,to,rate\n2023-01-01,EUR,USD,-1",
+		"date,from,to\n2023-01-01,EUR,USD",
+	} {
+		if _, err := LoadCSVRates(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected err for %q", bad)
+		}
+	}
+}
+
+func TestCurrencyFuncs(t *testing.T) {
+	rates, err := LoadCSVRates(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := LoadCSVPriceIndex(strings.NewReader(testPrices))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `exchange(100EUR, "USD", "2023-06-30")`, expected: "108.66USD"},
+		{expr: `exchange(spend, "USD", "2023-06-30")`, expected: "108.66USD"},
+		{expr: `exchange(0.5kg/EUR, "USD", "2023-01-02")`, expected: "0.4672897196261682kg/USD"},
+		{expr: `exchange(2EUR/kg, "USD", "2023-01-02")`, expected: "2.14USD/kg"},
+		{expr: `inflate(100USD, 2015, 2023)`, expected: "125USD"},
+		{expr: `inflate(0.5kg/USD, 2015, 2023)`, expected: "0.4kg/USD"},
+		{expr: `100USD * 0.5kg/USD`, expected: "50kg"},
+		{expr: `exchange(spend, "USD", "2023-06-30") * 0.4kg/USD`, expected: "43.464kg"},
+		{expr: `100USD + 100EUR`},
+		{expr: `100USD > 100EUR`},
+		{expr: `exchange(100kg, "USD", "2023-06-30")`},
+		{expr: `exchange(100EUR, "kg", "2023-06-30")`},
+		{expr: `exchange(100EUR, "USD", "30/06/2023")`},
+		{expr: `exchange(100EUR, "JPY", "2023-06-30")`},
+		{expr: `inflate(100USD, 2015, 2020)`},
+		{expr: `inflate(100USD, 2015.5, 2023)`},
+	}
+	vars := map[string]string{"spend": "100EUR"}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(vars, WithRates(rates), WithPriceIndex(prices))
+			if err != nil {
+				t.Fatal(err)
+			}
+			outvars, err := intrp.Interpret(bytes.NewBufferString("a = " + c.expr + ";\nprint(a);"))
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", outvars["a"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outvars["a"].String(); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+
+	// no rates given
+	intrp, err := NewInterpreter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intrp.Interpret(bytes.NewBufferString(`a = exchange(100EUR, "USD", "2023-06-30");`)); err == nil {
+		t.Fatalf("expected err")
+	}
+
+	if _, err := Exchange(rates, nil, "USD", time.Now()); err == nil {
+		t.Fatalf("expected err of nil value")
+	}
+}
@@ -0,0 +1,160 @@
+package calcu
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+type measureValueObject struct {
+	Value string `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+func (mv *MeasureValue) MarshalText() ([]byte, error) {
+	return []byte(mv.String()), nil
+}
+
+func (mv *MeasureValue) UnmarshalText(text []byte) error {
+	ans, err := makeMeasureValueFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*mv = *ans
+	return nil
+}
+
+func (mv *MeasureValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mv.String())
+}
+
+func (mv *MeasureValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var obj measureValueObject
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		d, err := decimal.NewFromString(obj.Value)
+		if err != nil {
+			return fmt.Errorf("invalid measure value: %s", data)
+		}
+		if obj.Unit == "" {
+			*mv = MeasureValue{um: StdUm, value: d, unitless: true}
+			return nil
+		}
+		if !StdUm.IsUnit(obj.Unit) {
+			return fmt.Errorf("unit %s not found", obj.Unit)
+		}
+		*mv = MeasureValue{um: StdUm, value: d, unit: canonicalUnit(StdUm, obj.Unit)}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid measure value: %s", data)
+	}
+	return mv.UnmarshalText([]byte(s))
+}
+
+// MeasureValueObject is a measure value of the JSON object form, i.e.,
+// {"value":"110","unit":"kg"} instead of "110kg", both forms are
+// accepted when unmarshalling either type.
+type MeasureValueObject struct {
+	*MeasureValue
+}
+
+func (o MeasureValueObject) MarshalJSON() ([]byte, error) {
+	if o.MeasureValue == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(measureValueObject{Value: o.value.String(), Unit: o.unit})
+}
+
+func (o *MeasureValueObject) UnmarshalJSON(data []byte) error {
+	var mv MeasureValue
+	if err := mv.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	o.MeasureValue = &mv
+	return nil
+}
+
+// Scan implements sql.Scanner, the measure value is
+// stored as text, e.g., 110kg
+func (mv *MeasureValue) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case string:
+		return mv.UnmarshalText([]byte(s))
+	case []byte:
+		return mv.UnmarshalText(s)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into MeasureValue, use NullMeasureValue")
+	default:
+		return fmt.Errorf("cannot scan %T into MeasureValue", src)
+	}
+}
+
+// NullMeasureValue represents a measure value that may be NULL,
+// it implements sql.Scanner and driver.Valuer. MeasureValue itself
+// can not be a driver.Valuer because of its Value method.
+type NullMeasureValue struct {
+	MeasureValue *MeasureValue
+	Valid        bool
+}
+
+func (n *NullMeasureValue) Scan(src interface{}) error {
+	if src == nil {
+		n.MeasureValue, n.Valid = nil, false
+		return nil
+	}
+	var mv MeasureValue
+	if err := mv.Scan(src); err != nil {
+		return err
+	}
+	n.MeasureValue, n.Valid = &mv, true
+	return nil
+}
+
+func (n NullMeasureValue) Value() (driver.Value, error) {
+	if !n.Valid || n.MeasureValue == nil {
+		return nil, nil
+	}
+	return n.MeasureValue.String(), nil
+}
+
+// Scan implements sql.Scanner, the vars are
+// stored as a JSON object.
+func (m *MeasureVars) Scan(src interface{}) error {
+	var data []byte
+	switch s := src.(type) {
+	case string:
+		data = []byte(s)
+	case []byte:
+		data = s
+	case nil:
+		*m = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into MeasureVars", src)
+	}
+	vars := make(MeasureVars)
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return err
+	}
+	*m = vars
+	return nil
+}
+
+func (m MeasureVars) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,152 @@
+package calcu
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMeasureValueJSON(t *testing.T) {
+	cases := []struct {
+		mv     *MeasureValue
+		str    string
+		object string
+	}{
+		{mv: mustMV("110kg", false), str: `"110kg"`, object: `{"value":"110","unit":"kg"}`},
+		{mv: mustMV("-1.5kg", false), str: `"-1.5kg"`, object: `{"value":"-1.5","unit":"kg"}`},
+		{mv: mustMV("1.1E-04Gg/10^3m3", false), str: `"0.00011Gg/10^3m3"`, object: `{"value":"0.00011","unit":"Gg/10^3m3"}`},
+		{mv: mustMV("2(10^3m3)", false), str: `"2(10^3m3)"`, object: `{"value":"2","unit":"10^3m3"}`},
+		{mv: mustMV("0.5", true), str: `"0.5"`, object: `{"value":"0.5","unit":""}`},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for _, asObject := range []bool{false, true} {
+				var v interface{} = c.mv
+				expected := c.str
+				if asObject {
+					v = MeasureValueObject{c.mv}
+					expected = c.object
+				}
+				data, err := json.Marshal(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != expected {
+					t.Fatalf("expected %s, got %s", expected, data)
+				}
+				var got MeasureValue
+				if err := json.Unmarshal(data, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.unit != c.mv.unit || got.unitless != c.mv.unitless || !got.value.Equal(c.mv.value) {
+					t.Fatalf("expected %v, got %v", c.mv, &got)
+				}
+			}
+		})
+	}
+
+	// the object form in structs, and both forms are accepted
+	type doc struct {
+		Total MeasureValueObject  `json:"total"`
+		Prev  *MeasureValueObject `json:"prev,omitempty"`
+	}
+	data, err := json.Marshal(doc{Total: MeasureValueObject{mustMV("110kg", false)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"total":{"value":"110","unit":"kg"}}`; string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+	var got doc
+	if err := json.Unmarshal([]byte(`{"total":"2t","prev":{"value":"1","unit":"t"}}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Total.String() != "2t" || got.Prev.String() != "1t" {
+		t.Fatalf("expected 2t and 1t, got %v and %v", got.Total, got.Prev)
+	}
+}
+
+func TestMeasureValueUnmarshalErrors(t *testing.T) {
+	cases := []string{`"1xx"`, `"kg"`, `{"value":"a","unit":"kg"}`, `{"value":"1","unit":"xx"}`, `1`, `[]`}
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			var mv MeasureValue
+			if err := json.Unmarshal([]byte(c), &mv); err == nil {
+				t.Fatalf("expected err, got %v", &mv)
+			}
+		})
+	}
+}
+
+func TestMeasureVarsJSON(t *testing.T) {
+	type doc struct {
+		Total *MeasureValue `json:"total"`
+		Vars  MeasureVars   `json:"vars"`
+	}
+	in := doc{
+		Total: mustMV("1217.2kg", false),
+		Vars:  MeasureVars{"CO2": mustMV("110kg", false), "ratio": mustMV("0.1", true)},
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"total":"1217.2kg","vars":{"CO2":"110kg","ratio":"0.1"}}`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+	var out doc
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Total.String() != "1217.2kg" || out.Vars["CO2"].String() != "110kg" || !out.Vars["ratio"].unitless {
+		t.Fatalf("unexpected round trip: %+v", out)
+	}
+}
+
+func TestMeasureValueSQL(t *testing.T) {
+	var mv MeasureValue
+	if err := mv.Scan([]byte("110kg")); err != nil {
+		t.Fatal(err)
+	}
+	if mv.String() != "110kg" {
+		t.Fatalf("expected 110kg, got %v", &mv)
+	}
+	if err := mv.Scan(nil); err == nil {
+		t.Fatalf("expected err scanning NULL")
+	}
+	if err := mv.Scan(1); err == nil {
+		t.Fatalf("expected err scanning int")
+	}
+
+	var n NullMeasureValue
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Fatalf("expected invalid, got %+v, %v", n, err)
+	}
+	v, err := n.Value()
+	if err != nil || v != nil {
+		t.Fatalf("expected nil value, got %v, %v", v, err)
+	}
+	if err := n.Scan("2(10^3m3)"); err != nil || !n.Valid {
+		t.Fatalf("expected valid, got %+v, %v", n, err)
+	}
+	v, err = n.Value()
+	if err != nil || v != "2(10^3m3)" {
+		t.Fatalf("expected 2(10^3m3), got %v, %v", v, err)
+	}
+
+	vars := MeasureVars{"CO2": mustMV("110kg", false), "ratio": mustMV("0.1", true)}
+	v, err = vars.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got MeasureVars
+	if err := got.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{got["CO2"].String(), got["ratio"].String()}
+	if !reflect.DeepEqual([]string{"110kg", "0.1"}, gots) {
+		t.Fatalf("unexpected round trip: %v", gots)
+	}
+}
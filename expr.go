@@ -7,6 +7,8 @@ import __yyfmt__ "fmt"
 
 //line expr.y:2
 
+import "fmt"
+
 func setErr(exprlex exprLexer, err error) int {
 	exprlex.(*lexer).setErr(err)
 	return 1
@@ -16,13 +18,16 @@ func setRoot(exprlex exprLexer, node Node) {
 	exprlex.(*lexer).setRoot(node)
 }
 
-//line expr.y:15
+//line expr.y:17
 type exprSymType struct {
-	yys   int
-	token int
-	str   string
+	yys    int
+	token  int
+	str    string
+	quoted bool
+	pos    int
 
 	list *List
+	strs []string
 	node Node
 }
 
@@ -31,6 +36,18 @@ const NUM = 57347
 const UNIT = 57348
 const LITERALSTR = 57349
 const LITERALMV = 57350
+const EQ = 57351
+const NE = 57352
+const FUNC = 57353
+const IMPORT = 57354
+const INPUT = 57355
+const DEFAULT = 57356
+const RANGE = 57357
+const OUTPUT = 57358
+const IN = 57359
+const AS = 57360
+const DESC = 57361
+const UMINUS = 57362
 
 var exprToknames = [...]string{
 	"$end",
@@ -41,15 +58,31 @@ var exprToknames = [...]string{
 	"UNIT",
 	"LITERALSTR",
 	"LITERALMV",
+	"EQ",
+	"NE",
+	"FUNC",
+	"IMPORT",
+	"INPUT",
+	"DEFAULT",
+	"RANGE",
+	"OUTPUT",
+	"IN",
+	"AS",
+	"DESC",
 	"'+'",
 	"'-'",
 	"'*'",
 	"'/'",
+	"UMINUS",
+	"'%'",
 	"'='",
 	"'('",
 	"')'",
 	"';'",
 	"','",
+	"':'",
+	"'['",
+	"']'",
 }
 
 var exprStatenames = [...]string{}
@@ -58,80 +91,122 @@ const exprEofCode = 1
 const exprErrCode = 2
 const exprInitialStackSize = 16
 
-//line expr.y:156
+//line expr.y:302
 
 //line yacctab:1
 var exprExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 22,
-	14, 16,
-	-2, 7,
+	-1, 34,
+	27, 27,
+	-2, 13,
+	-1, 65,
+	9, 0,
+	10, 0,
+	-2, 15,
+	-1, 66,
+	9, 0,
+	10, 0,
+	-2, 16,
 }
 
 const exprPrivate = 57344
 
-const exprLast = 60
+const exprLast = 130
 
 var exprAct = [...]int8{
-	13, 7, 6, 17, 15, 12, 14, 16, 8, 19,
-	20, 27, 28, 18, 10, 25, 26, 27, 28, 30,
-	31, 37, 23, 9, 24, 29, 33, 34, 35, 36,
-	32, 17, 15, 2, 14, 16, 5, 19, 17, 15,
-	1, 18, 16, 21, 19, 22, 15, 3, 18, 16,
-	11, 19, 4, 0, 0, 18, 25, 26, 27, 28,
+	29, 46, 47, 88, 73, 41, 74, 28, 44, 81,
+	45, 19, 48, 49, 50, 51, 18, 52, 17, 46,
+	47, 52, 39, 16, 15, 92, 14, 40, 20, 72,
+	48, 49, 50, 51, 21, 52, 59, 54, 55, 56,
+	90, 34, 30, 33, 32, 31, 78, 65, 66, 67,
+	68, 69, 70, 64, 62, 46, 47, 38, 37, 43,
+	57, 86, 84, 76, 36, 26, 48, 49, 50, 51,
+	79, 52, 23, 80, 71, 50, 51, 85, 52, 63,
+	53, 82, 87, 34, 30, 33, 32, 31, 60, 89,
+	25, 91, 24, 22, 35, 2, 46, 47, 83, 38,
+	37, 1, 7, 75, 6, 5, 36, 48, 49, 50,
+	51, 4, 52, 48, 49, 50, 51, 9, 52, 3,
+	58, 27, 77, 61, 10, 11, 12, 42, 8, 13,
 }
 
 var exprPact = [...]int16{
-	32, -1000, -14, -15, -6, 10, -1000, -1000, -1, 41,
-	-1000, 7, -1000, 47, -1000, 19, -1000, -1000, 34, 34,
-	47, -1000, -1000, -1000, 27, 34, 34, 34, 34, -1000,
-	6, -1000, -1000, 0, 0, -1000, -1000, -1000,
+	113, -32768, -3, -5, -6, -11, -13, -18, 1, 8,
+	89, 65, 88, 86, -32768, -32768, -32768, -32768, -32768, -32768,
+	37, 79, 0, -32768, -26, 42, -32768, -20, -32768, 87,
+	74, -32768, -32768, -32768, -32768, -32768, 79, 79, 79, 87,
+	32, 84, 36, 73, -32768, 79, 79, 79, 79, 79,
+	79, 79, -32768, -32768, 46, -4, -4, 3, -24, -32768,
+	49, 27, 63, -32768, -32768, 93, 93, 53, 53, -4,
+	-4, -32768, 79, -17, 77, 47, 79, -32768, 54, -32768,
+	87, 79, -32768, -32768, -29, 87, -32768, 87, 79, 10,
+	79, -8, -32768,
 }
 
-var exprPgo = [...]int8{
-	0, 52, 50, 0, 33, 5, 47, 40,
+var exprPgo = [...]uint8{
+	0, 128, 127, 123, 122, 121, 120, 0, 94, 7,
+	119, 111, 105, 104, 103, 102, 101, 98,
 }
 
 var exprR1 = [...]int8{
-	0, 7, 7, 7, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 4, 4, 1, 2, 2, 5,
-	5, 6, 6,
+	0, 16, 16, 16, 16, 16, 16, 16, 7, 7,
+	7, 7, 7, 7, 7, 7, 7, 7, 7, 7,
+	7, 7, 7, 7, 7, 8, 8, 1, 5, 5,
+	9, 10, 11, 11, 12, 13, 14, 14, 17, 17,
+	15, 2, 2, 3, 3, 4, 4, 6, 6,
 }
 
 var exprR2 = [...]int8{
-	0, 0, 2, 2, 2, 1, 1, 1, 3, 3,
-	3, 3, 3, 2, 3, 4, 1, 1, 3, 1,
-	1, 3, 3,
+	0, 0, 2, 2, 2, 2, 2, 2, 2, 1,
+	1, 1, 1, 1, 1, 3, 3, 3, 3, 3,
+	3, 3, 2, 2, 2, 3, 4, 1, 1, 3,
+	1, 3, 6, 7, 2, 6, 0, 2, 0, 6,
+	5, 0, 2, 0, 2, 0, 2, 1, 3,
 }
 
 var exprChk = [...]int16{
-	-1000, -7, -4, -6, -1, 4, 16, 16, 14, 13,
-	15, -2, -5, -3, 7, 5, 8, 4, 14, 10,
-	-3, -4, 4, 15, 17, 9, 10, 11, 12, 6,
-	-3, -3, -5, -3, -3, -3, -3, 15,
+	-32768, -16, -8, -10, -11, -12, -13, -15, -1, 4,
+	11, 12, 13, 16, 29, 29, 29, 29, 29, 29,
+	27, 26, 4, 7, 4, 4, 28, -5, -9, -7,
+	5, 8, 7, 6, 4, -8, 27, 21, 20, -7,
+	27, 31, -2, 17, 28, 30, 9, 10, 20, 21,
+	22, 23, 25, 6, -7, -7, -7, 28, -6, 4,
+	4, -3, 18, 6, -9, -7, -7, -7, -7, -7,
+	-7, 28, 26, 28, 30, -14, 14, -4, 19, 7,
+	-7, 26, 4, -17, 15, -7, 7, -7, 32, -7,
+	30, -7, 33,
 }
 
 var exprDef = [...]int8{
-	1, -2, 0, 0, 0, 16, 2, 3, 0, 0,
-	14, 0, 17, 19, 20, 6, 5, 7, 0, 0,
-	21, 22, -2, 15, 0, 0, 0, 0, 0, 4,
-	0, 13, 18, 8, 9, 10, 11, 12,
+	1, -2, 0, 0, 0, 0, 0, 0, 0, 27,
+	0, 0, 0, 0, 2, 3, 4, 5, 6, 7,
+	0, 0, 0, 34, 0, 41, 25, 0, 28, 30,
+	10, 9, 11, 12, -2, 14, 0, 0, 0, 31,
+	0, 0, 43, 0, 26, 0, 0, 0, 0, 0,
+	0, 0, 24, 8, 0, 22, 23, 0, 0, 47,
+	36, 45, 0, 42, 29, -2, -2, 17, 18, 19,
+	20, 21, 0, 0, 0, 38, 0, 40, 0, 44,
+	32, 0, 48, 35, 0, 37, 46, 33, 0, 0,
+	0, 0, 39,
 }
 
 var exprTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 25, 3, 3,
+	27, 28, 22, 20, 30, 21, 3, 23, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 31, 29,
+	3, 26, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	14, 15, 11, 9, 17, 10, 3, 12, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 16,
-	3, 13,
+	3, 32, 3, 33,
 }
 
 var exprTok2 = [...]int8{
-	2, 3, 4, 5, 6, 7, 8,
+	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
+	12, 13, 14, 15, 16, 17, 18, 19, 24,
 }
 
 var exprTok3 = [...]int8{
@@ -177,7 +252,7 @@ func exprNewParser() exprParser {
 	return &exprParserImpl{}
 }
 
-const exprFlag = -1000
+const exprFlag = -32768
 
 func exprTokname(c int) string {
 	if c >= 1 && c-1 < len(exprToknames) {
@@ -477,25 +552,49 @@ exprdefault:
 
 	case 1:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
-//line expr.y:36
+//line expr.y:46
 		{
 			setRoot(exprlex, nil)
 		}
 	case 2:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:37
+//line expr.y:47
 		{
 			setRoot(exprlex, exprDollar[1].node)
 		}
 	case 3:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:38
+//line expr.y:48
 		{
 			setRoot(exprlex, exprDollar[1].node)
 		}
 	case 4:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:42
+//line expr.y:49
+		{
+			setRoot(exprlex, exprDollar[1].node)
+		}
+	case 5:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:50
+		{
+			setRoot(exprlex, exprDollar[1].node)
+		}
+	case 6:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:51
+		{
+			setRoot(exprlex, exprDollar[1].node)
+		}
+	case 7:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:52
+		{
+			setRoot(exprlex, exprDollar[1].node)
+		}
+	case 8:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:56
 		{
 			n, err := makeMeasureValue(exprDollar[1].str, exprDollar[2].str)
 			if err != nil {
@@ -503,9 +602,9 @@ exprdefault:
 			}
 			exprVAL.node = n
 		}
-	case 5:
+	case 9:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:50
+//line expr.y:64
 		{
 			n, err := makeMeasureValueFromString(exprDollar[1].str)
 			if err != nil {
@@ -513,9 +612,9 @@ exprdefault:
 			}
 			exprVAL.node = n
 		}
-	case 6:
+	case 10:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:58
+//line expr.y:72
 		{
 			n, err := makeUnitlessMeasureValue(exprDollar[1].str)
 			if err != nil {
@@ -523,97 +622,138 @@ exprdefault:
 			}
 			exprVAL.node = n
 		}
-	case 7:
+	case 11:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:66
+//line expr.y:80
+		{
+			exprVAL.node = makeLiteralString(exprDollar[1].str)
+		}
+	case 12:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:84
+		{
+			// a unit alone is only meaningful as a
+			// quoted string, e.g., "kg"
+			if !exprDollar[1].quoted {
+				return setErr(exprlex, fmt.Errorf("unexpected unit %s", exprDollar[1].str))
+			}
+			exprVAL.node = makeLiteralString(exprDollar[1].str)
+		}
+	case 13:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:93
 		{
 			exprVAL.node = makeVariable(exprDollar[1].str)
 		}
-	case 8:
+	case 14:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:97
+		{
+			exprVAL.node = exprDollar[1].node
+		}
+	case 15:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:70
+//line expr.y:101
+		{
+			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "==")
+		}
+	case 16:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:105
+		{
+			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "!=")
+		}
+	case 17:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:109
 		{
 			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "+")
 		}
-	case 9:
+	case 18:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:74
+//line expr.y:113
 		{
 			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "-")
 		}
-	case 10:
+	case 19:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:78
+//line expr.y:117
 		{
 			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "*")
 		}
-	case 11:
+	case 20:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:82
+//line expr.y:121
 		{
 			exprVAL.node = makeBinaryExpr(exprDollar[1].node, exprDollar[3].node, "/")
 		}
-	case 12:
+	case 21:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:86
+//line expr.y:125
 		{
 			exprVAL.node = makeParenExpr(exprDollar[2].node)
 		}
-	case 13:
+	case 22:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:90
+//line expr.y:129
 		{
-			exprVAL.node = makeUnaryExpr(exprDollar[2].node)
+			exprVAL.node = makeUnaryExpr(exprDollar[2].node, "-")
 		}
-	case 14:
+	case 23:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:133
+		{
+			exprVAL.node = makeUnaryExpr(exprDollar[2].node, "+")
+		}
+	case 24:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:137
+		{
+			exprVAL.node = makeUnaryExpr(exprDollar[1].node, "%")
+		}
+	case 25:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:96
+//line expr.y:143
 		{
-			n, err := makeFuncCall(exprDollar[1].str)
+			n, err := makeFuncCall(exprDollar[1].str, exprDollar[1].pos)
 			if err != nil {
 				return setErr(exprlex, err)
 			}
 			exprVAL.node = n
 		}
-	case 15:
+	case 26:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:104
+//line expr.y:151
 		{
-			n, err := makeFuncCall(exprDollar[1].str, exprDollar[3].list.elements...)
+			n, err := makeFuncCall(exprDollar[1].str, exprDollar[1].pos, exprDollar[3].list.elements...)
 			if err != nil {
 				return setErr(exprlex, err)
 			}
 			exprVAL.node = n
 		}
-	case 17:
+	case 28:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:117
+//line expr.y:164
 		{
 			l := makeList()
 			l.Append(exprDollar[1].node)
 			exprVAL.list = l
 		}
-	case 18:
+	case 29:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:123
+//line expr.y:170
 		{
 			exprVAL.list.Append(exprDollar[3].node)
 		}
-	case 19:
+	case 30:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:129
+//line expr.y:176
 		{
 			exprVAL.node = exprDollar[1].node
 		}
-	case 20:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:133
-		{
-			exprVAL.node = makeLiteralString(exprDollar[1].str)
-		}
-	case 21:
+	case 31:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:139
+//line expr.y:182
 		{
 			n, err := makeAssignment(exprDollar[1].str, exprDollar[3].node)
 			if err != nil {
@@ -621,16 +761,131 @@ exprdefault:
 			}
 			exprVAL.node = n
 		}
-	case 22:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:147
+	case 32:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:192
 		{
-			n, err := makeAssignment(exprDollar[1].str, exprDollar[3].node)
+			n, err := makeFuncDef(exprDollar[2].str, nil, exprDollar[6].node)
 			if err != nil {
 				return setErr(exprlex, err)
 			}
 			exprVAL.node = n
 		}
+	case 33:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:200
+		{
+			n, err := makeFuncDef(exprDollar[2].str, exprDollar[4].strs, exprDollar[7].node)
+			if err != nil {
+				return setErr(exprlex, err)
+			}
+			exprVAL.node = n
+		}
+	case 34:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:210
+		{
+			n, err := makeImport(exprDollar[2].str)
+			if err != nil {
+				return setErr(exprlex, err)
+			}
+			exprVAL.node = n
+		}
+	case 35:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:220
+		{
+			n, err := makeInput(exprDollar[2].str, exprDollar[4].str, exprDollar[5].node, exprDollar[6].list)
+			if err != nil {
+				return setErr(exprlex, err)
+			}
+			exprVAL.node = n
+		}
+	case 36:
+		exprDollar = exprS[exprpt-0 : exprpt+1]
+//line expr.y:230
+		{
+			exprVAL.node = nil
+		}
+	case 37:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:234
+		{
+			exprVAL.node = exprDollar[2].node
+		}
+	case 38:
+		exprDollar = exprS[exprpt-0 : exprpt+1]
+//line expr.y:240
+		{
+			exprVAL.list = nil
+		}
+	case 39:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:244
+		{
+			l := makeList()
+			l.Append(exprDollar[3].node)
+			l.Append(exprDollar[5].node)
+			exprVAL.list = l
+		}
+	case 40:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:253
+		{
+			n, err := makeOutput(exprDollar[2].str, exprDollar[3].str, exprDollar[4].str, exprDollar[5].str)
+			if err != nil {
+				return setErr(exprlex, err)
+			}
+			exprVAL.node = n
+		}
+	case 41:
+		exprDollar = exprS[exprpt-0 : exprpt+1]
+//line expr.y:263
+		{
+			exprVAL.str = ""
+		}
+	case 42:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:267
+		{
+			exprVAL.str = exprDollar[2].str
+		}
+	case 43:
+		exprDollar = exprS[exprpt-0 : exprpt+1]
+//line expr.y:273
+		{
+			exprVAL.str = ""
+		}
+	case 44:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:277
+		{
+			exprVAL.str = exprDollar[2].str
+		}
+	case 45:
+		exprDollar = exprS[exprpt-0 : exprpt+1]
+//line expr.y:283
+		{
+			exprVAL.str = ""
+		}
+	case 46:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:287
+		{
+			exprVAL.str = exprDollar[2].str
+		}
+	case 47:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:293
+		{
+			exprVAL.strs = []string{exprDollar[1].str}
+		}
+	case 48:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:297
+		{
+			exprVAL.strs = append(exprDollar[1].strs, exprDollar[3].str)
+		}
 	}
 	goto exprstack /* stack new state and value */
 }
@@ -1,6 +1,8 @@
 %{
 package calcu
 
+import "fmt"
+
 func setErr(exprlex exprLexer, err error) int {
     exprlex.(*lexer).setErr(err)
     return 1
@@ -15,19 +17,27 @@ func setRoot(exprlex exprLexer, node Node) {
 %union {
     token int
     str string
+    quoted bool
+    pos int
 
     list *List
+    strs []string
     node Node
 }
 
-%token<str> IDENT NUM UNIT LITERALSTR LITERALMV
+%token<str> IDENT NUM UNIT LITERALSTR LITERALMV EQ NE FUNC IMPORT INPUT DEFAULT RANGE OUTPUT IN AS DESC
 
-%type<str> func_name
+%type<str> func_name output_unit output_label output_desc
 %type<list> func_arg_list
-%type<node> a_expr func_call func_arg_expr assignment statement
+%type<strs> func_param_list
+%type<node> a_expr func_call func_arg_expr assignment func_def import input input_default output statement
+%type<list> input_range
 
+%nonassoc  EQ NE
 %left      '+' '-'
 %left      '*' '/'
+%right     UMINUS
+%left      '%'
 %nonassoc  '='
 %left      '(' ')'
 
@@ -36,6 +46,10 @@ func setRoot(exprlex exprLexer, node Node) {
 statement:/* empty */ {setRoot(exprlex, nil)}
          | func_call ';' {setRoot(exprlex, $1)}
          | assignment ';' {setRoot(exprlex, $1)}
+         | func_def ';' {setRoot(exprlex, $1)}
+         | import ';' {setRoot(exprlex, $1)}
+         | input ';' {setRoot(exprlex, $1)}
+         | output ';' {setRoot(exprlex, $1)}
          ;
 
 a_expr: NUM UNIT
@@ -62,10 +76,35 @@ a_expr: NUM UNIT
           }
           $$ = n
         }
+      | LITERALSTR
+        {
+          $$ = makeLiteralString($1)
+        }
+      | UNIT
+        {
+          // a unit alone is only meaningful as a
+          // quoted string, e.g., "kg"
+          if !$<quoted>1 {
+              return setErr(exprlex, fmt.Errorf("unexpected unit %s", $1))
+          }
+          $$ = makeLiteralString($1)
+        }
       | IDENT
         {
           $$ = makeVariable($1)
         }
+      | func_call
+        {
+          $$ = $1
+        }
+      | a_expr EQ a_expr
+        {
+          $$ = makeBinaryExpr($1, $3, "==")
+        }
+      | a_expr NE a_expr
+        {
+          $$ = makeBinaryExpr($1, $3, "!=")
+        }
       | a_expr '+' a_expr
         {
           $$ = makeBinaryExpr($1, $3, "+")
@@ -86,15 +125,23 @@ a_expr: NUM UNIT
         {
           $$ = makeParenExpr($2)
         }
-      | '-' a_expr %prec '*'
+      | '-' a_expr %prec UMINUS
+        {
+          $$ = makeUnaryExpr($2, "-")
+        }
+      | '+' a_expr %prec UMINUS
+        {
+          $$ = makeUnaryExpr($2, "+")
+        }
+      | a_expr '%'
         {
-          $$ = makeUnaryExpr($2)
+          $$ = makeUnaryExpr($1, "%")
         }
       ;
 
 func_call: func_name '(' ')'
            {
-             n, err := makeFuncCall($1)
+             n, err := makeFuncCall($1, $<pos>1)
              if err != nil {
                  return setErr(exprlex, err)
              }
@@ -102,7 +149,7 @@ func_call: func_name '(' ')'
            }
          | func_name '(' func_arg_list ')'
            {
-             n, err := makeFuncCall($1, $3.elements...)
+             n, err := makeFuncCall($1, $<pos>1, $3.elements...)
              if err != nil {
                  return setErr(exprlex, err)
              }
@@ -129,10 +176,6 @@ func_arg_expr: a_expr
                {
                  $$ = $1
                }
-              | LITERALSTR
-               {
-                 $$ = makeLiteralString($1)
-               }
              ;
 
 assignment: IDENT '=' a_expr
@@ -143,14 +186,117 @@ assignment: IDENT '=' a_expr
               }
               $$ = n
             }
-	  | IDENT '=' func_call
-            {
-              n, err := makeAssignment($1, $3)
-              if err != nil {
-                  return setErr(exprlex, err)
-              }
-              $$ = n
+           ;
+
+func_def: FUNC IDENT '(' ')' '=' a_expr
+          {
+            n, err := makeFuncDef($2, nil, $6)
+            if err != nil {
+                return setErr(exprlex, err)
             }
+            $$ = n
+          }
+        | FUNC IDENT '(' func_param_list ')' '=' a_expr
+          {
+            n, err := makeFuncDef($2, $4, $7)
+            if err != nil {
+                return setErr(exprlex, err)
+            }
+            $$ = n
+          }
+        ;
+
+import: IMPORT LITERALSTR
+        {
+          n, err := makeImport($2)
+          if err != nil {
+              return setErr(exprlex, err)
+          }
+          $$ = n
+        }
+      ;
+
+input: INPUT IDENT ':' IDENT input_default input_range
+       {
+         n, err := makeInput($2, $4, $5, $6)
+         if err != nil {
+             return setErr(exprlex, err)
+         }
+         $$ = n
+       }
+     ;
+
+input_default: /* empty */
+               {
+                 $$ = nil
+               }
+             | DEFAULT a_expr
+               {
+                 $$ = $2
+               }
+             ;
+
+input_range: /* empty */
+             {
+               $$ = nil
+             }
+           | RANGE '[' a_expr ',' a_expr ']'
+             {
+               l := makeList()
+               l.Append($3)
+               l.Append($5)
+               $$ = l
+             }
            ;
 
+output: OUTPUT IDENT output_unit output_label output_desc
+        {
+          n, err := makeOutput($2, $3, $4, $5)
+          if err != nil {
+              return setErr(exprlex, err)
+          }
+          $$ = n
+        }
+      ;
+
+output_unit: /* empty */
+             {
+               $$ = ""
+             }
+           | IN UNIT
+             {
+               $$ = $2
+             }
+           ;
+
+output_label: /* empty */
+              {
+                $$ = ""
+              }
+            | AS LITERALSTR
+              {
+                $$ = $2
+              }
+            ;
+
+output_desc: /* empty */
+             {
+               $$ = ""
+             }
+           | DESC LITERALSTR
+             {
+               $$ = $2
+             }
+           ;
+
+func_param_list: IDENT
+                 {
+                   $$ = []string{$1}
+                 }
+               | func_param_list ',' IDENT
+                 {
+                   $$ = append($1, $3)
+                 }
+               ;
+
 %%
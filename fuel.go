@@ -0,0 +1,192 @@
+package calcu
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Fuel is the properties of a fuel
+type Fuel struct {
+	Name  string
+	Label string
+	// NCV is the net calorific value, energy per mass, e.g., 43Tj/Gg
+	NCV *MeasureValue
+	// Density is mass per volume, e.g., 840kg/m3,
+	// nil if unknown, e.g., for the solid fuels.
+	Density *MeasureValue
+}
+
+// FuelRegistry looks up the fuel properties by name, the names
+// are case-insensitive, e.g., diesel, natural_gas.
+type FuelRegistry struct {
+	m map[string]Fuel
+}
+
+//go:embed fuel.csv
+var fuelAsset embed.FS
+
+// NewFuelRegistry creates a registry with the IPCC 2006 default
+// NCVs and the typical densities, the fuels can be overridden
+// by Set.
+func NewFuelRegistry() *FuelRegistry {
+	r := &FuelRegistry{m: make(map[string]Fuel)}
+	f, _ := fuelAsset.Open("fuel.csv")
+	rd := csv.NewReader(f)
+	for rowid := 0; ; rowid++ {
+		record, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+		if rowid == 0 {
+			continue // skip header row
+		}
+		fuel := Fuel{Name: record[0], Label: record[1]}
+		if fuel.NCV, err = NewMeasureValueFromString(record[2]); err != nil {
+			panic(err)
+		}
+		if record[3] != "" {
+			if fuel.Density, err = NewMeasureValueFromString(record[3]); err != nil {
+				panic(err)
+			}
+		}
+		if err := r.Set(fuel); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Set adds or overrides the fuel
+func (r *FuelRegistry) Set(fuel Fuel) error {
+	if fuel.Name == "" {
+		return fmt.Errorf("fuel name is required")
+	}
+	if !isCompoundOf(fuel.NCV, DimEnergy, DimMass) {
+		return fmt.Errorf("fuel %s: expect NCV as energy per mass, e.g., 43Tj/Gg, got %v", fuel.Name, fuel.NCV)
+	}
+	if fuel.Density != nil && !isCompoundOf(fuel.Density, DimMass, DimVolume) {
+		return fmt.Errorf("fuel %s: expect density as mass per volume, e.g., 840kg/m3, got %v", fuel.Name, fuel.Density)
+	}
+	if fuel.Label == "" {
+		fuel.Label = fuel.Name
+	}
+	r.m[strings.ToLower(fuel.Name)] = fuel
+	return nil
+}
+
+func (r *FuelRegistry) Get(name string) (Fuel, bool) {
+	fuel, ok := r.m[strings.ToLower(name)]
+	return fuel, ok
+}
+
+// Names lists the fuel names ordered
+func (r *FuelRegistry) Names() []string {
+	names := make([]string, 0, len(r.m))
+	for _, fuel := range r.m {
+		names = append(names, fuel.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithFuels sets the fuel registry of the energy, mass
+// and volume funcs, the default is NewFuelRegistry().
+func WithFuels(r *FuelRegistry) Option {
+	return func(i *Interpreter) {
+		i.fuels = r
+	}
+}
+
+// stdFuels is the default registry, it's read only.
+var stdFuels = NewFuelRegistry()
+
+// isCompoundOf check if mv is of a compound unit num/den, e.g., Tj/Gg
+func isCompoundOf(mv *MeasureValue, num, den Dimension) bool {
+	if mv == nil || mv.unitless {
+		return false
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return false
+	}
+	cu, ok := u.(*CompoundUnit)
+	return ok && cu.Numerator.dimension == num && cu.Denominator.dimension == den
+}
+
+// fuelMass converts the fuel use v of energy, mass or volume to kg
+func (r *FuelRegistry) fuelMass(v *MeasureValue, name string) (*MeasureValue, Fuel, error) {
+	fuel, ok := r.Get(name)
+	if !ok {
+		return nil, fuel, fmt.Errorf("unknown fuel %s", name)
+	}
+	if v == nil {
+		return nil, fuel, fmt.Errorf("found undefined value")
+	}
+	mu := v.metaUnit()
+	if mu == nil {
+		return nil, fuel, fmt.Errorf("expect fuel use of energy, mass or volume, got %s", v)
+	}
+	si := siValue(mu, v.value)
+	switch mu.dimension {
+	case DimMass:
+	case DimEnergy:
+		u, _ := fuel.NCV.um.GetByName(fuel.NCV.unit)
+		si = si.Div(siValue(u, fuel.NCV.value))
+	case DimVolume:
+		if fuel.Density == nil {
+			return nil, fuel, fmt.Errorf("density of fuel %s is unknown", fuel.Name)
+		}
+		u, _ := fuel.Density.um.GetByName(fuel.Density.unit)
+		// the volume of the reference conditions, e.g., Nm3,
+		// is not mixed with the others, e.g., m3.
+		if den := u.(*CompoundUnit).Denominator; den.si != mu.si {
+			return nil, fuel, fmt.Errorf("expect fuel use convertible to %s of the density of fuel %s, got %s%s", den.name, fuel.Name, v, gasHint(mu.name, den.name))
+		}
+		si = si.Mul(siValue(u, fuel.Density.value))
+	default:
+		return nil, fuel, fmt.Errorf("expect fuel use of energy, mass or volume, got %s", v)
+	}
+	return &MeasureValue{um: v.um, unit: "kg", value: si}, fuel, nil
+}
+
+// energy is the kernel func converts the fuel use to energy
+// in the energy unit of the NCV, e.g., Tj.
+func (i *Interpreter) energy(v *MeasureValue, fuel string) (*MeasureValue, error) {
+	mass, f, err := i.fuels.fuelMass(v, fuel)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := f.NCV.um.GetByName(f.NCV.unit)
+	cu := u.(*CompoundUnit)
+	d := mass.value.Mul(siValue(cu, f.NCV.value))
+	return &MeasureValue{um: v.um, unit: cu.Numerator.name, value: fromSiValue(cu.Numerator, d)}, nil
+}
+
+// mass is the kernel func converts the fuel use to kg
+func (i *Interpreter) mass(v *MeasureValue, fuel string) (*MeasureValue, error) {
+	ans, _, err := i.fuels.fuelMass(v, fuel)
+	return ans, err
+}
+
+// volume is the kernel func converts the fuel use to
+// the volume unit of the density, e.g., m3.
+func (i *Interpreter) volume(v *MeasureValue, fuel string) (*MeasureValue, error) {
+	mass, f, err := i.fuels.fuelMass(v, fuel)
+	if err != nil {
+		return nil, err
+	}
+	if f.Density == nil {
+		return nil, fmt.Errorf("density of fuel %s is unknown", f.Name)
+	}
+	u, _ := f.Density.um.GetByName(f.Density.unit)
+	cu := u.(*CompoundUnit)
+	d := mass.value.Div(siValue(cu, f.Density.value))
+	return &MeasureValue{um: v.um, unit: cu.Denominator.name, value: fromSiValue(cu.Denominator, d)}, nil
+}
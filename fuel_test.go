@@ -0,0 +1,119 @@
+package calcu
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFuelFuncs(t *testing.T) {
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `energy(1000ltr, "diesel")`, expected: "0.03612Tj"},
+		{expr: `energy(1Gg, "Diesel")`, expected: "43Tj"},
+		{expr: `energy(fuel_use, fuel)`, expected: "0.03612Tj"},
+		{expr: `mass(1000ltr, "diesel")`, expected: "840kg"},
+		{expr: `mass(0.03612Tj, "diesel")`, expected: "840kg"},
+		{expr: `mass(1000Nm3, "natural_gas")`, expected: "717kg"},
+		{expr: `volume(840kg, "diesel")`, expected: "1m3"},
+		{expr: `volume(0.3612Tj, "diesel")`, expected: "10m3"},
+		{expr: `volume(717kg, "natural_gas")`, expected: "1000Nm3"},
+		{expr: `mass(1kg, "unknown")`},
+		{expr: `volume(1kg, "anthracite")`},
+		{expr: `mass(1h, "diesel")`},
+		{expr: `mass(1000m3, "natural_gas")`},
+		{expr: `mass(1000scf, "natural_gas")`},
+		{expr: `mass(1, "diesel")`},
+		{expr: `mass(1kg/m3, "diesel")`},
+	}
+	vars := map[string]string{"fuel_use": "1000ltr", "fuel": "diesel"}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outvars, err := intrp.Interpret(bytes.NewBufferString("a = " + c.expr + ";\nprint(a);"))
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", outvars["a"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outvars["a"].String(); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestFuelRegistry(t *testing.T) {
+	r := NewFuelRegistry()
+	diesel, ok := r.Get("diesel")
+	if !ok || diesel.Label != "Gas/Diesel Oil" || diesel.NCV.String() != "43Tj/Gg" || diesel.Density.String() != "840kg/m3" {
+		t.Fatalf("unexpected diesel: %+v", diesel)
+	}
+	if err := r.Set(Fuel{Name: "diesel", NCV: mustMV("42Tj/Gg", false), Density: mustMV("0.85kg/ltr", false)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(Fuel{Name: "hvo", Label: "Hydrotreated Vegetable Oil", NCV: mustMV("44Tj/Gg", false)}); err != nil {
+		t.Fatal(err)
+	}
+	intrp, err := NewInterpreter(nil, WithFuels(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(`a = energy(1Gg, "diesel");
+b = mass(1m3, "diesel");
+c = energy(1Gg, "hvo");
+print(a, b, c);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["a"].String(), outvars["b"].String(), outvars["c"].String()}
+	expected := []string{"42Tj", "850kg", "44Tj"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+	// the default registry is untouched
+	if diesel, _ := stdFuels.Get("diesel"); diesel.NCV.String() != "43Tj/Gg" {
+		t.Fatalf("unexpected default diesel: %+v", diesel)
+	}
+
+	for _, fuel := range []Fuel{
+		{NCV: mustMV("42Tj/Gg", false)},
+		{Name: "a"},
+		{Name: "a", NCV: mustMV("42kg/m3", false)},
+		{Name: "a", NCV: mustMV("42Tj/Gg", false), Density: mustMV("1kg", false)},
+	} {
+		if err := r.Set(fuel); err == nil {
+			t.Fatalf("expected err for %+v", fuel)
+		}
+	}
+}
+
+func TestFuelFuncsShadowed(t *testing.T) {
+	intrp, err := NewInterpreter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(`func energy(x) = x * 2;
+a = energy(1kg);
+print(a);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outvars["a"].String(); got != "2kg" {
+		t.Fatalf("expected 2kg, got %s", got)
+	}
+	// print is not shadowable
+	if _, err := intrp.Interpret(bytes.NewBufferString(`func print(x) = x;`)); err == nil {
+		t.Fatalf("expected err")
+	}
+}
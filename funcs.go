@@ -0,0 +1,290 @@
+package calcu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// FuncOption configures the func registered by RegisterFunc
+type FuncOption func(*function)
+
+// FuncArgNames names the args of the func, one for each
+// param, the name of variadic param names all the rest.
+func FuncArgNames(names ...string) FuncOption {
+	return func(f *function) {
+		f.argNames = names
+	}
+}
+
+// FuncDoc documents the func
+func FuncDoc(doc string) FuncOption {
+	return func(f *function) {
+		f.doc = doc
+	}
+}
+
+var funcNameRe = regexp.MustCompile("^[_a-zA-Z][_a-zA-Z0-9]*$")
+
+// isFunc reports whether fn is a non-nil func
+func isFunc(fn interface{}) bool {
+	if fn == nil || reflect.TypeOf(fn).Kind() != reflect.Func {
+		return false
+	}
+	return !reflect.ValueOf(fn).IsNil()
+}
+
+// RegisterFunc registers the go func fn visible to scripts
+// as name, unlike the funcs passed to NewInterpreter, the
+// name is not derived from the go func name, so closures
+// and methods can be registered without name collisions.
+func (i *Interpreter) RegisterFunc(name string, fn interface{}, opts ...FuncOption) error {
+	if !funcNameRe.MatchString(name) {
+		return fmt.Errorf("invalid func name %q", name)
+	}
+	if !isFunc(fn) {
+		return fmt.Errorf("expect func for %s, got %T", name, fn)
+	}
+	fi := getFuncInfo(fn)
+	fi.funcName = name
+	for _, opt := range opts {
+		opt(fi)
+	}
+	if fi.argNames != nil && len(fi.argNames) != len(fi.paramTypes) {
+		return fmt.Errorf("func %s expects %d arg names, got %d", name, len(fi.paramTypes), len(fi.argNames))
+	}
+	return i.registerFunc(fi)
+}
+
+// FuncInfo describes a func callable from scripts
+type FuncInfo struct {
+	Name string
+	// Args are the arg names, might be empty
+	// if the func is registered without names.
+	Args []string
+	// ArgTypes are the go types of the args,
+	// empty for script funcs.
+	ArgTypes []string
+	// Returns are the go types of the returns
+	Returns []string
+	Doc     string
+	// Arity is the number of args, the variadic
+	// arg is counted as one.
+	Arity    int
+	Variadic bool
+	// Kernel reports the builtin func
+	Kernel bool
+	// Script reports the func defined by scripts
+	Script bool
+}
+
+// Signature formats the func as name(arg type, ...) returns
+func (fi FuncInfo) Signature() string {
+	buf := bytes.NewBufferString(fi.Name)
+	buf.WriteString("(")
+	for k := 0; k < fi.Arity; k++ {
+		if k > 0 {
+			buf.WriteString(", ")
+		}
+		var name, typ string
+		if k < len(fi.Args) {
+			name = fi.Args[k]
+		}
+		if k < len(fi.ArgTypes) {
+			typ = fi.ArgTypes[k]
+			if fi.Variadic && k == fi.Arity-1 {
+				typ = "..." + typ
+			}
+		}
+		switch {
+		case name != "" && typ != "":
+			buf.WriteString(name + " " + typ)
+		case name != "":
+			buf.WriteString(name)
+		default:
+			buf.WriteString(typ)
+		}
+	}
+	buf.WriteString(")")
+	switch len(fi.Returns) {
+	case 0:
+	case 1:
+		buf.WriteString(" " + fi.Returns[0])
+	default:
+		buf.WriteString(" (")
+		for k, r := range fi.Returns {
+			if k > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(r)
+		}
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// Funcs lists the funcs callable from scripts ordered by name,
+// including the kernel funcs and the script defined funcs.
+func (i *Interpreter) Funcs() []FuncInfo {
+	var ans []FuncInfo
+	for name, f := range i.kfuncs {
+		if _, ok := i.funcs[name]; ok && f.shadowable {
+			continue
+		}
+		fi := f.info()
+		fi.Kernel = true
+		ans = append(ans, fi)
+	}
+	for name, f := range i.funcs {
+		fi := f.info()
+		// the imported funcs are listed by namespaced name
+		fi.Name = name
+		ans = append(ans, fi)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Name < ans[j].Name
+	})
+	return ans
+}
+
+func (f *function) info() FuncInfo {
+	if f.def != nil {
+		return FuncInfo{
+			Name:   f.funcName,
+			Args:   f.def.params,
+			Arity:  len(f.def.params),
+			Script: true,
+		}
+	}
+	variadic := f.fnValue.Type().IsVariadic()
+	argTypes := make([]string, len(f.paramTypes))
+	for k, t := range f.paramTypes {
+		argTypes[k] = typeName(t)
+		if variadic && k == len(f.paramTypes)-1 {
+			argTypes[k] = typeName(t.Elem())
+		}
+	}
+	returns := make([]string, len(f.returnTypes))
+	for k, t := range f.returnTypes {
+		returns[k] = typeName(t)
+	}
+	return FuncInfo{
+		Name:     f.funcName,
+		Args:     f.argNames,
+		ArgTypes: argTypes,
+		Returns:  returns,
+		Doc:      f.doc,
+		Arity:    len(f.paramTypes),
+		Variadic: variadic,
+	}
+}
+
+// typeName names the type without package path
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Interface:
+		if t.Name() == "" {
+			return "interface{}"
+		}
+	}
+	// the types out of this package are qualified, e.g., decimal.Decimal
+	if t.PkgPath() != "" && t.PkgPath() != mvType.PkgPath() {
+		return t.String()
+	}
+	return t.Name()
+}
+
+var (
+	mvType      = reflect.TypeOf(MeasureValue{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	float64Type = reflect.TypeOf(float64(0))
+)
+
+// coerceArgs validates the args against the params of the func
+// and converts them to the param types, a unitless *MeasureValue
+// is accepted as decimal.Decimal or float64 param.
+func (f *function) coerceArgs(ctx context.Context, args []interface{}) ([]reflect.Value, error) {
+	if f.def != nil {
+		// script func takes variadic args, check
+		// the arity against its definition.
+		if len(args) != len(f.def.params) {
+			return nil, fmt.Errorf("expects %d args, got %d", len(f.def.params), len(args))
+		}
+	}
+	n := len(f.paramTypes)
+	variadic := f.fnValue.Type().IsVariadic()
+	if variadic && len(args) < n-1 {
+		return nil, fmt.Errorf("expects at least %d args, got %d", n-1, len(args))
+	}
+	if !variadic && len(args) != n {
+		return nil, fmt.Errorf("expects %d args, got %d", n, len(args))
+	}
+	rargs := make([]reflect.Value, len(args))
+	for k, arg := range args {
+		idx := k
+		if variadic && k >= n-1 {
+			idx = n - 1
+		}
+		pt := f.paramTypes[idx]
+		if variadic && idx == n-1 {
+			pt = pt.Elem()
+		}
+		v, err := coerceArg(arg, pt)
+		if err != nil {
+			if idx < len(f.argNames) {
+				return nil, fmt.Errorf("arg %s: %v", f.argNames[idx], err)
+			}
+			return nil, fmt.Errorf("arg %d: %v", k+1, err)
+		}
+		rargs[k] = v
+	}
+	if f.withCtx {
+		rargs = append([]reflect.Value{reflect.ValueOf(ctx)}, rargs...)
+	}
+	return rargs, nil
+}
+
+func coerceArg(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	if mv, ok := arg.(*MeasureValue); ok {
+		switch t {
+		case decimalType, float64Type:
+			if mv == nil {
+				return reflect.Value{}, errors.New("found undefined value")
+			}
+			if !mv.unitless {
+				return reflect.Value{}, fmt.Errorf("cannot use %s as %s, expect unitless value", mv, typeName(t))
+			}
+			if t == decimalType {
+				return reflect.ValueOf(mv.value), nil
+			}
+			return reflect.ValueOf(mv.value.InexactFloat64()), nil
+		case mvType:
+			if mv == nil {
+				return reflect.Value{}, errors.New("found undefined value")
+			}
+			return reflect.ValueOf(*mv), nil
+		}
+	}
+	if arg == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s", typeName(t))
+	}
+	v := reflect.ValueOf(arg)
+	if !v.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("cannot use %s as %s", typeName(v.Type()), typeName(t))
+	}
+	return v, nil
+}
@@ -0,0 +1,199 @@
+package calcu
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type Converter struct {
+	factor *MeasureValue
+}
+
+func (c *Converter) Convert(mv *MeasureValue) (*MeasureValue, error) {
+	return mv.Mul(c.factor)
+}
+
+func TestRegisterFunc(t *testing.T) {
+	intrp, err := NewInterpreter(map[string]string{"a": "2kg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 := &Converter{factor: mustMV("2", true)}
+	c2 := &Converter{factor: mustMV("3", true)}
+	if err := intrp.RegisterFunc("double", c1.Convert, FuncArgNames("mv"), FuncDoc("double the value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := intrp.RegisterFunc("triple", c2.Convert); err != nil {
+		t.Fatal(err)
+	}
+	add := func(a, b *MeasureValue) (*MeasureValue, error) {
+		return a.Add(b)
+	}
+	if err := intrp.RegisterFunc("add", add, FuncArgNames("a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	exprs := `
+func quad(a) = double(double(a));
+b = double(a);
+c = triple(a);
+d = add(b, c);
+e = quad(a);
+print(b, c, d, e);
+`
+	outvars, err := intrp.Interpret(bytes.NewBufferString(exprs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["b"].String(), outvars["c"].String(), outvars["d"].String(), outvars["e"].String()}
+	expected := []string{"4kg", "6kg", "10kg", "8kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+
+	var sigs []string
+	for _, fi := range intrp.Funcs() {
+		sigs = append(sigs, fi.Signature())
+	}
+	expected = []string{
+		"add(a *MeasureValue, b *MeasureValue) (*MeasureValue, error)",
+		"double(mv *MeasureValue) (*MeasureValue, error)",
+		"energy(fuel_use *MeasureValue, fuel string) (*MeasureValue, error)",
+		"exchange(v *MeasureValue, currency string, date string) (*MeasureValue, error)",
+		"gasmass(v *MeasureValue, molar_mass *MeasureValue) (*MeasureValue, error)",
+		"inflate(v *MeasureValue, from_year decimal.Decimal, to_year decimal.Decimal) (*MeasureValue, error)",
+		"mass(fuel_use *MeasureValue, fuel string) (*MeasureValue, error)",
+		"normalize(v *MeasureValue, temp interface{}, pressure interface{}) (*MeasureValue, error)",
+		"print(vars ...interface{}) error",
+		"quad(a)",
+		"restate(v *MeasureValue, unit string) (*MeasureValue, error)",
+		"triple(*MeasureValue) (*MeasureValue, error)",
+		"volume(fuel_use *MeasureValue, fuel string) (*MeasureValue, error)",
+	}
+	if !reflect.DeepEqual(expected, sigs) {
+		t.Fatalf("exptectd: %v, got: %v", expected, sigs)
+	}
+	fis := intrp.Funcs()
+	if fis[1].Doc != "double the value" || fis[1].Arity != 1 || fis[1].Variadic {
+		t.Fatalf("unexpected func info: %+v", fis[1])
+	}
+	if !fis[8].Kernel || !fis[8].Variadic || !fis[9].Script {
+		t.Fatalf("unexpected func info: %+v, %+v", fis[8], fis[9])
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   interface{}
+		opts []FuncOption
+		hint string
+	}{
+		{name: "print", fn: fInt, hint: "overwriting kernel func"},
+		{name: "fInt", fn: fInt, hint: "reregistered func"},
+		{name: "a.b", fn: fInt, hint: "invalid func name"},
+		{name: "f", fn: 1, hint: "not a func"},
+		{name: "f", fn: nil, hint: "nil"},
+		{name: "f", fn: (func())(nil), hint: "nil func"},
+		{name: "f", fn: fInt, opts: []FuncOption{FuncArgNames("a", "b")}, hint: "mismatched arg names"},
+		{name: "f", fn: func() int { return 1 }, hint: "unsupported return type"},
+	}
+	for _, c := range cases {
+		t.Run(c.hint, func(t *testing.T) {
+			intrp, err := NewInterpreter(nil, fInt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = intrp.RegisterFunc(c.name, c.fn, c.opts...)
+			if err == nil {
+				t.Fatalf("expected err, got nil")
+			}
+			t.Log(err)
+		})
+	}
+
+	for _, fn := range []interface{}{nil, (func())(nil), 1} {
+		if _, err := NewInterpreter(nil, fn); err == nil {
+			t.Fatalf("expected err of %T", fn)
+		}
+	}
+}
+
+func TestFuncArgCoercion(t *testing.T) {
+	scale := func(mv *MeasureValue, d decimal.Decimal) *MeasureValue {
+		return MakeMeasureValueFromDecimal(mv.Value().Mul(d), mv.Unit())
+	}
+	pow := func(f float64, n float64) *MeasureValue {
+		return MakeMeasureValueFromDecimal(decimal.NewFromFloat(math.Pow(f, n)), "kg")
+	}
+	sum := func(label string, mvs ...*MeasureValue) (*MeasureValue, error) {
+		ans := mustMV("0kg", false)
+		for _, mv := range mvs {
+			var err error
+			if ans, err = ans.Add(mv); err != nil {
+				return nil, err
+			}
+		}
+		return ans, nil
+	}
+	value := func(mv MeasureValue) *MeasureValue {
+		return &mv
+	}
+	intrp, err := NewInterpreter(map[string]string{"a": "2kg", "r": "0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fns := map[string]interface{}{"scale": scale, "pow": pow, "sum": sum, "value": value}
+	for name, fn := range fns {
+		if err := intrp.RegisterFunc(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exprs := `
+b = scale(a, r);
+c = pow(2, 3);
+d = sum("total", a, b, 1t);
+e = sum("empty");
+f = value(a);
+print(b, c, d, e, f);
+`
+	outvars, err := intrp.Interpret(bytes.NewBufferString(exprs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["b"].String(), outvars["c"].String(), outvars["d"].String(),
+		outvars["e"].String(), outvars["f"].String()}
+	expected := []string{"1kg", "8kg", "1003kg", "0kg", "2kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+
+	cases := []struct {
+		expr string
+		hint string
+	}{
+		{expr: "x = scale(a);", hint: "expects 2 args"},
+		{expr: "x = scale(a, a);", hint: "decimal param expects unitless"},
+		{expr: `x = pow("2", 3);`, hint: "string as float64"},
+		{expr: "x = sum();", hint: "expects at least 1 arg"},
+		{expr: `x = sum("s", "t");`, hint: "string as variadic *MeasureValue"},
+		{expr: `x = scale(a, undefined);`, hint: "undefined value"},
+		{expr: "func f(a) = a;\nx = f(1, 2);", hint: "script func arity"},
+	}
+	for _, c := range cases {
+		t.Run(c.hint, func(t *testing.T) {
+			_, err := intrp.Interpret(bytes.NewBufferString(c.expr))
+			if err == nil {
+				t.Fatalf("expected err, got nil")
+			}
+			if !strings.Contains(err.Error(), "col") {
+				t.Fatalf("expected call position in error, got: %v", err)
+			}
+			t.Log(err)
+		})
+	}
+}
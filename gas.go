@@ -0,0 +1,213 @@
+package calcu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// gasRef is the reference conditions of a gas volume unit, temp
+// in K and pressure in Pa. Each of the units is its own si, i.e.,
+// they are not convertible to m3 or each other but by restate, and
+// m3 is the geometric volume of the unit at the conditions.
+type gasRef struct {
+	temp     decimal.Decimal
+	pressure decimal.Decimal
+	m3       decimal.Decimal
+}
+
+var (
+	gasRefs = map[string]gasRef{
+		// normal, 0°C and 101.325kPa
+		"Nm3": {temp: mustSiValue("0°C"), pressure: mustSiValue("101.325kPa"), m3: decimal.NewFromInt(1)},
+		// standard, 15°C and 101.325kPa
+		"Sm3": {temp: mustSiValue("15°C"), pressure: mustSiValue("101.325kPa"), m3: decimal.NewFromInt(1)},
+		// standard, 60°F and 14.696psi
+		"scf": {temp: mustSiValue("60°F"), pressure: mustSiValue("14.696psi"), m3: decimal.RequireFromString("0.028316846592")},
+	}
+
+	// gasConstant is the molar gas constant R in J/(mol.K)
+	gasConstant = decimal.RequireFromString("8.314462618")
+)
+
+func mustSiValue(s string) decimal.Decimal {
+	mv, err := NewMeasureValueFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	u, _ := mv.um.GetByName(mv.unit)
+	return siValue(u, mv.value)
+}
+
+// gasVolume returns the meta unit of the volume v
+func gasVolume(v *MeasureValue) (*MetaUnit, error) {
+	if v == nil {
+		return nil, fmt.Errorf("found undefined value")
+	}
+	mu := v.metaUnit()
+	if mu == nil || mu.dimension != DimVolume {
+		return nil, fmt.Errorf("expect gas volume, got %s", v)
+	}
+	return mu, nil
+}
+
+// gasRefVolume returns the meta unit and the reference conditions of the volume v
+func gasRefVolume(v *MeasureValue) (*MetaUnit, gasRef, error) {
+	mu, err := gasVolume(v)
+	if err != nil {
+		return nil, gasRef{}, err
+	}
+	ref, ok := gasRefs[mu.name]
+	if !ok {
+		return nil, gasRef{}, fmt.Errorf("expect gas volume at reference conditions, e.g., Nm3, Sm3 or scf, got %s", v)
+	}
+	return mu, ref, nil
+}
+
+// Normalize converts the gas volume v measured at the temperature temp
+// and the pressure to Nm3, i.e., the volume at 0°C and 101.325kPa.
+func Normalize(v, temp, pressure *MeasureValue) (*MeasureValue, error) {
+	mu, err := gasVolume(v)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := gasRefs[mu.name]; ok {
+		return nil, fmt.Errorf("%s is at reference conditions already, use restate", v)
+	}
+	t, err := absoluteTemp(temp)
+	if err != nil {
+		return nil, err
+	}
+	p, err := gasPressure(pressure)
+	if err != nil {
+		return nil, err
+	}
+	return restate(siValue(mu, v.value), gasRef{temp: t, pressure: p, m3: decimal.NewFromInt(1)}, "Nm3"), nil
+}
+
+// Restate converts the gas volume v at reference conditions to
+// another reference conditions unit, e.g., 1000scf to Nm3.
+func Restate(v *MeasureValue, unit string) (*MeasureValue, error) {
+	mu, ref, err := gasRefVolume(v)
+	if err != nil {
+		return nil, err
+	}
+	unit = canonicalUnit(v.um, unit)
+	if _, ok := gasRefs[unit]; !ok {
+		return nil, fmt.Errorf("expect reference conditions unit, e.g., Nm3, Sm3 or scf, got %s", unit)
+	}
+	return restate(siValue(mu, v.value), ref, unit), nil
+}
+
+// restate converts the volume d in the units of ref to unit
+// by the ideal gas law, i.e., p1V1/T1 = p2V2/T2.
+func restate(d decimal.Decimal, ref gasRef, unit string) *MeasureValue {
+	tref := gasRefs[unit]
+	d = d.Mul(ref.m3).Mul(ref.pressure).Mul(tref.temp).Div(tref.pressure.Mul(ref.temp)).Div(tref.m3)
+	return &MeasureValue{um: StdUm, unit: unit, value: d}
+}
+
+// gasHint hints the conversion of the volumes at reference
+// conditions, e.g., scf to Nm3 is by restate, not by To.
+func gasHint(unit, other string) string {
+	_, ok := gasRefs[unit]
+	_, ook := gasRefs[other]
+	if !ok && !ook {
+		return ""
+	}
+	u, _ := StdUm.GetByName(unit)
+	ou, _ := StdUm.GetByName(other)
+	mu, _ := u.(*MetaUnit)
+	omu, _ := ou.(*MetaUnit)
+	if mu == nil || omu == nil || mu.dimension != DimVolume || omu.dimension != DimVolume {
+		return ""
+	}
+	return ", see restate and normalize"
+}
+
+// GasMass converts the gas volume v at reference conditions to mass by
+// the ideal gas law, the molarMass is mass per amount, e.g., 16.04g/mol
+// for methane.
+func GasMass(v, molarMass *MeasureValue) (*MeasureValue, error) {
+	mu, ref, err := gasRefVolume(v)
+	if err != nil {
+		return nil, err
+	}
+	if molarMass == nil || molarMass.unitless {
+		return nil, fmt.Errorf("expect molar mass, e.g., 16.04g/mol, got %v", molarMass)
+	}
+	u, ok := molarMass.um.GetByName(molarMass.unit)
+	cu, isCompound := u.(*CompoundUnit)
+	if !ok || !isCompound || cu.Numerator.dimension != DimMass || cu.Denominator.dimension != DimAmount {
+		return nil, fmt.Errorf("expect molar mass, e.g., 16.04g/mol, got %s", molarMass)
+	}
+	// n = pV/RT
+	n := ref.pressure.Mul(siValue(mu, v.value).Mul(ref.m3)).Div(gasConstant.Mul(ref.temp))
+	return (&MeasureValue{um: v.um, unit: "mol", value: n}).Mul(molarMass)
+}
+
+func absoluteTemp(temp *MeasureValue) (decimal.Decimal, error) {
+	mu := temp.metaUnit()
+	if mu == nil || mu.dimension != DimTemperature || mu.isDelta() {
+		return decimal.Zero, fmt.Errorf("expect temperature, e.g., 15°C, got %s", temp)
+	}
+	d := siValue(mu, temp.value)
+	if d.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("expect temperature above absolute zero, got %s", temp)
+	}
+	return d, nil
+}
+
+func gasPressure(pressure *MeasureValue) (decimal.Decimal, error) {
+	mu := pressure.metaUnit()
+	if mu == nil || mu.dimension != DimPressure {
+		return decimal.Zero, fmt.Errorf("expect pressure, e.g., 101.325kPa, got %s", pressure)
+	}
+	d := siValue(mu, pressure.value)
+	if d.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("expect positive pressure, got %s", pressure)
+	}
+	return d, nil
+}
+
+// gasCondition parses the temperature or pressure passed by
+// scripts, either a measure value or a string, the string
+// might spell °C and °F as C and F, e.g., "15C".
+func gasCondition(arg interface{}) (*MeasureValue, error) {
+	switch a := arg.(type) {
+	case *MeasureValue:
+		if a == nil {
+			return nil, fmt.Errorf("found undefined value")
+		}
+		return a, nil
+	case string:
+		mv, err := NewMeasureValueFromString(a)
+		if err == nil {
+			return mv, nil
+		}
+		for _, suffix := range []string{"C", "F"} {
+			if strings.HasSuffix(a, suffix) && !strings.HasSuffix(a, "°"+suffix) {
+				if mv, err := NewMeasureValueFromString(strings.TrimSuffix(a, suffix) + "°" + suffix); err == nil {
+					return mv, nil
+				}
+			}
+		}
+		return nil, err
+	default:
+		return nil, fmt.Errorf("expect measure value, got %T", arg)
+	}
+}
+
+// normalize is the kernel func of Normalize
+func normalize(v *MeasureValue, temp, pressure interface{}) (*MeasureValue, error) {
+	t, err := gasCondition(temp)
+	if err != nil {
+		return nil, err
+	}
+	p, err := gasCondition(pressure)
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(v, t, p)
+}
@@ -0,0 +1,158 @@
+package calcu
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		v        string
+		temp     string
+		pressure string
+		expected string
+	}{
+		{v: "288.15m3", temp: "15°C", pressure: "101.325kPa", expected: "273.15Nm3"},
+		{v: "1m3", temp: "0°C", pressure: "2atm", expected: "2Nm3"},
+		{v: "1000ltr", temp: "273.15K", pressure: "1.01325bar", expected: "1Nm3"},
+		{v: "1(10^3m3)", temp: "15°C", pressure: "101.325kPa", expected: "947.94Nm3"},
+		{v: "1Nm3", temp: "15°C", pressure: "101.325kPa", expected: ""},
+		{v: "1kg", temp: "15°C", pressure: "101.325kPa", expected: ""},
+		{v: "1m3", temp: "15Δ°C", pressure: "101.325kPa", expected: ""},
+		{v: "1m3", temp: "-300°C", pressure: "101.325kPa", expected: ""},
+		{v: "1m3", temp: "15°C", pressure: "1kg", expected: ""},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := Normalize(mustMV(c.v, false), mustMV(c.temp, false), mustMV(c.pressure, false))
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Round(2).String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestRestate(t *testing.T) {
+	cases := []struct {
+		v        string
+		unit     string
+		expected string
+	}{
+		{v: "273.15Nm3", unit: "Sm3", expected: "288.15Sm3"},
+		{v: "288.15Sm3", unit: "Nm3", expected: "273.15Nm3"},
+		{v: "1000scf", unit: "Nm3", expected: "26.79Nm3"},
+		{v: "1Sm3", unit: "scf", expected: "35.38scf"},
+		{v: "1m3", unit: "Nm3", expected: ""},
+		{v: "1Nm3", unit: "m3", expected: ""},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := Restate(mustMV(c.v, false), c.unit)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Round(2).String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestGasMass(t *testing.T) {
+	cases := []struct {
+		v          string
+		molarMass  string
+		expected   string
+		unitlessMM bool
+	}{
+		{v: "1Nm3", molarMass: "16.04g/mol", expected: "0.7156kg"},
+		{v: "1Sm3", molarMass: "44.01kg/kmol", expected: "1.8613kg"},
+		{v: "1m3", molarMass: "16.04g/mol", expected: ""},
+		{v: "1Nm3", molarMass: "16.04", unitlessMM: true, expected: ""},
+		{v: "1Nm3", molarMass: "16.04kg/m3", expected: ""},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := GasMass(mustMV(c.v, false), mustMV(c.molarMass, c.unitlessMM))
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Round(4).String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestInterpreterGasFuncs(t *testing.T) {
+	exprs := `
+n = normalize(activity_value, "15C", "101.325kPa");
+s = normalize(activity_value, 59°F, 1atm);
+f = restate(1000scf, "Nm3");
+CH4 = gasmass(n, 16.04g/mol);
+print(n, s, f, CH4);
+`
+	vars := map[string]string{"activity_value": "288.15m3"}
+	intrp, err := NewInterpreter(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(exprs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gots []string
+	for _, name := range []string{"n", "s", "f", "CH4"} {
+		gots = append(gots, outvars[name].Round(2).String())
+	}
+	expected := []string{"273.15Nm3", "273.15Nm3", "26.79Nm3", "195.47kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+
+	for _, expr := range []string{
+		`a = 1Nm3 + 1scf;`,
+		`a = 1Sm3 - 1m3;`,
+		`a = 1Nm3 > 1Sm3;`,
+		`a = normalize(activity_value, "hot", "101.325kPa");`,
+		`a = normalize(activity_value, "15C", 1);`,
+		`a = gasmass(activity_value, 16.04g/mol);`,
+	} {
+		intrp, err := NewInterpreter(vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := intrp.Interpret(bytes.NewBufferString(expr)); err == nil {
+			t.Fatalf("expected err for %s", expr)
+		}
+	}
+
+	// the volumes at reference conditions are converted by restate only
+	if _, err := mustMV("1000scf", false).To("Nm3"); err == nil || !strings.Contains(err.Error(), "see restate and normalize") {
+		t.Fatalf("expected the hint of restate, got %v", err)
+	}
+}
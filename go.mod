@@ -2,4 +2,9 @@ module github.com/maxnilz/calcu
 
 go 1.20
 
-require github.com/shopspring/decimal v1.3.1
+require (
+	github.com/shopspring/decimal v1.3.1
+	golang.org/x/term v0.15.0
+)
+
+require golang.org/x/sys v0.15.0 // indirect
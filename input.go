@@ -0,0 +1,132 @@
+package calcu
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// checkInputs validates the input vars by the input declarations of
+// the script before any statement is evaluated, the missing inputs
+// with a default are set to the default. All the invalid inputs and
+// the assignments to the inputs are reported together.
+func (i *Interpreter) checkInputs(stmts []Stmt) error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, s := range stmts {
+		a, ok := s.Node.(*Input)
+		if !ok {
+			continue
+		}
+		if seen[a.name] {
+			errs = append(errs, fmt.Errorf("line %d: input %s redeclared", s.Line, a.name))
+			continue
+		}
+		seen[a.name] = true
+		if err := i.checkInput(a); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", s.Line, err))
+		}
+	}
+	for _, s := range stmts {
+		if asg, ok := s.Node.(*Assignment); ok && seen[asg.variable] {
+			errs = append(errs, fmt.Errorf("line %d: cannot assign to input %s", s.Line, asg.variable))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (i *Interpreter) checkInput(a *Input) error {
+	v, given := i.lookupVar(a.name)
+	if !given {
+		if a.def == nil {
+			return fmt.Errorf("missing input %s", a.name)
+		}
+		def, err := i.visitAExpr(a.def)
+		if err != nil {
+			return fmt.Errorf("default of input %s: %w", a.name, err)
+		}
+		v = def
+	}
+	mv, ok := v.(*MeasureValue)
+	if !ok {
+		return fmt.Errorf("input %s: expect %s, got %q", a.name, a.dimName(), v.(*LiteralString).s)
+	}
+	u, ok := i.inputUnit(a, mv)
+	if !ok {
+		return fmt.Errorf("input %s: expect %s, got %s", a.name, a.dimName(), mv)
+	}
+	if a.lo != nil {
+		lo, losi, err := i.inputBound(a, a.lo, u)
+		if err != nil {
+			return err
+		}
+		hi, hisi, err := i.inputBound(a, a.hi, u)
+		if err != nil {
+			return err
+		}
+		if losi.GreaterThan(hisi) {
+			return fmt.Errorf("range of input %s: %s greater than %s", a.name, lo, hi)
+		}
+		if si := inputSi(mv, u); si.LessThan(losi) || si.GreaterThan(hisi) {
+			return fmt.Errorf("input %s: %s out of range [%s, %s]", a.name, mv, lo, hi)
+		}
+	}
+	if !given {
+		i.setVar(a.name, mv)
+	}
+	return nil
+}
+
+// inputBound evaluates the bound of the input range in si, a
+// unitless bound is of the si unit of the input unit u, e.g., m3,
+// and it's returned of the si unit, e.g., 0 is 0m3. The u is nil
+// of the unitless inputs, whose bounds are unitless.
+func (i *Interpreter) inputBound(a *Input, n Node, u *MetaUnit) (*MeasureValue, decimal.Decimal, error) {
+	v, err := i.visitAExpr(n)
+	if err != nil {
+		return nil, decimal.Zero, fmt.Errorf("range of input %s: %w", a.name, err)
+	}
+	mv, ok := v.(*MeasureValue)
+	if !ok {
+		return nil, decimal.Zero, fmt.Errorf("range of input %s: expect %s, got %q", a.name, a.dimName(), v.(*LiteralString).s)
+	}
+	if mv.unitless && !a.unitless {
+		return &MeasureValue{um: mv.um, unit: u.si, value: mv.value}, mv.value, nil
+	}
+	bu, ok := i.inputUnit(a, mv)
+	if !ok {
+		return nil, decimal.Zero, fmt.Errorf("range of input %s: expect %s, got %s", a.name, a.dimName(), mv)
+	}
+	return mv, inputSi(mv, bu), nil
+}
+
+// inputUnit returns the unit of mv if it's of the input, the unit
+// is nil of the unitless inputs.
+func (i *Interpreter) inputUnit(a *Input, mv *MeasureValue) (*MetaUnit, bool) {
+	if a.unitless {
+		return nil, mv.unitless
+	}
+	return i.dimUnit(mv, a.dim)
+}
+
+// inputSi returns the value of mv in si, the value as is if u is nil
+func inputSi(mv *MeasureValue, u *MetaUnit) decimal.Decimal {
+	if u == nil {
+		return mv.value
+	}
+	return mv.toSi(u).value
+}
+
+// dimUnit returns the unit of mv if it's of the dimension
+func (i *Interpreter) dimUnit(mv *MeasureValue, dim Dimension) (*MetaUnit, bool) {
+	if mv.unitless {
+		return nil, false
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return nil, false
+	}
+	mu, ok := u.(*MetaUnit)
+	return mu, ok && mu.dimension == dim
+}
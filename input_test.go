@@ -0,0 +1,110 @@
+package calcu
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInputs(t *testing.T) {
+	script := `input activity_value: Volume default 0m3 range [0, 1e9];
+input factor: Mass default 1.1E-04Gg range [0kg, 100kg];
+CO2 = activity_value / 1m3 * factor;
+print(CO2);
+`
+	cases := []struct {
+		vars     map[string]string
+		expected string
+		errs     []string
+	}{
+		{vars: map[string]string{"activity_value": "2m3", "factor": "3kg"}, expected: "6kg"},
+		{vars: map[string]string{"activity_value": "2(10^3m3)", "factor": "3kg"}, expected: "6000kg"},
+		{vars: map[string]string{"factor": "3kg"}, expected: "0kg"},
+		{vars: map[string]string{"activity_value": "2m3", "factor": "0.1t"}, expected: "0.2t"},
+		{vars: map[string]string{"activity_value": "2m3"},
+			errs: []string{"line 2: input factor: 0.00011Gg out of range [0kg, 100kg]"}},
+		{vars: map[string]string{"activity_value": "2kg", "factor": "3"},
+			errs: []string{"line 1: input activity_value: expect Volume, got 2kg", "line 2: input factor: expect Mass, got 3"}},
+		{vars: map[string]string{"activity_value": "-1m3", "factor": "diesel"},
+			errs: []string{"line 1: input activity_value: -1m3 out of range [0m3, 1000000000m3]", `line 2: input factor: expect Mass, got "diesel"`}},
+		{vars: map[string]string{"activity_value": "2E9m3", "factor": "1.5t"},
+			errs: []string{"out of range [0m3, 1000000000m3]", "out of range [0kg, 100kg]"}},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(c.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outvars, err := intrp.Interpret(strings.NewReader(script))
+			if len(c.errs) > 0 {
+				if err == nil {
+					t.Fatalf("expected err, got %v", outvars)
+				}
+				for _, e := range c.errs {
+					if !strings.Contains(err.Error(), e) {
+						t.Fatalf("expected err %q, got %v", e, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outvars["CO2"].String(); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestInputDecls(t *testing.T) {
+	cases := []struct {
+		script string
+		err    string
+	}{
+		{script: "input a: Mass;\nb = a * 2;", err: "line 1: missing input a"},
+		{script: "input a: Mass;\ninput a: Mass;", err: "line 2: input a redeclared"},
+		{script: "input a: Weight;", err: "line 1: unknown dimension Weight of input a"},
+		{script: "input a: Mass default 1m3;", err: "line 1: input a: expect Mass, got 1m3"},
+		{script: "input a: Mass default 1kg range [0m3, 1];", err: "line 1: range of input a: expect Mass, got 0m3"},
+		{script: "input a: Mass default b;", err: "line 1: default of input a: found undefined var b"},
+		{script: "input a: Mass range [0kg];", err: "line 1: syntax error"},
+		{script: "input a.b: Mass;", err: "line 1: invalid input a.b"},
+		{script: "input a: Mass default 1kg range [1t, 0kg];", err: "line 1: range of input a: 1t greater than 0kg"},
+		{script: "input a: Temperature default 20degC range [0, 100];", err: "line 1: input a: 20°C out of range [0K, 100K]"},
+		{script: "a = 1kg;\ninput a: Mass default 1kg;", err: "line 1: cannot assign to input a"},
+		{script: "input x: Mass;\nb = 1kg;\nx = b * 2;", err: "line 3: cannot assign to input x"},
+		// the evaluation is not started if any input is invalid
+		{script: "print(x);\ninput a: Mass;", err: "line 2: missing input a"},
+		{script: "input a: Temperature default 20degC range [-10degC, 40degC];\nprint(a);"},
+		{script: "input a: Mass default -1kg range [-2kg, 0kg];\nprint(a);"},
+		{script: "input r: Unitless default 0.5 range [0, 1];\nb = 2kg * r;\nprint(b);"},
+		{script: "input r: Unitless default 2 range [0, 1];", err: "line 1: input r: 2 out of range [0, 1]"},
+		{script: "input r: Unitless default 1kg;", err: "line 1: input r: expect Unitless, got 1kg"},
+		{script: "input r: Unitless default 1 range [0kg, 1];", err: "line 1: range of input r: expect Unitless, got 0kg"},
+		{script: "input a: Mass default 1;", err: "line 1: input a: expect Mass, got 1"},
+		{script: "input a: unitless;", err: "line 1: unknown dimension unitless of input a"},
+		// default and range are not reserved outside of the input declarations
+		{script: "default = 1kg;\nrange = default * 2;\nprint(range);"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(map[string]string{"x": "1kg"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = intrp.Interpret(bytes.NewBufferString(c.script))
+			if c.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected err %q, got %v", c.err, err)
+			}
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package calcu
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -28,84 +28,216 @@ func (m MeasureVars) Decimal(key string) decimal.NullDecimal {
 }
 
 type Interpreter struct {
-	mvvars MeasureVars
-	funcs  map[string]*function
-	kfuncs map[string]*function
+	mvvars  MeasureVars
+	strvars map[string]*LiteralString
+	funcs   map[string]*function
+	kfuncs  map[string]*function
+
+	// frames holds the params of the script
+	// func calls, the top one is in scope.
+	frames       []map[string]Node
+	maxCallDepth int
+
+	modules *moduleLoader
+	limits  *limiter
+	fuels   *FuelRegistry
+	rates   RateProvider
+	prices  PriceIndex
+	// locale of the input vars, nil is the number syntax of the scripts
+	locale *Locale
+
+	outvars MeasureVars
+	outstrs map[string]string
+	outputs []OutputInfo
+}
+
+// Option configures the interpreter, options are
+// passed to NewInterpreter along with user funcs.
+type Option func(*Interpreter)
 
-	outvars   MeasureVars
-	lastError error
+// WithMaxCallDepth limits the nesting of script
+// func calls, including recursive calls.
+func WithMaxCallDepth(n int) Option {
+	return func(i *Interpreter) {
+		i.maxCallDepth = n
+	}
 }
 
+const defaultMaxCallDepth = 64
+
+// NewInterpreter creates an interpreter with the given input vars.
+// An input starting with a digit, after an optional sign and dot, is
+// parsed as a measure value, e.g., "1kg" or "-.5t", and fails the
+// construction if it's not one, e.g., "1kgx". Anything else, e.g.,
+// "diesel", "-abc" or ".net", is kept as a string value. The
+// measure values are of the locale if WithLocale is given, e.g.,
+// "1.234,5 kg".
 func NewInterpreter(vars map[string]string, fns ...interface{}) (*Interpreter, error) {
-	mvvars := make(map[string]*MeasureValue)
+	intrp := newInterpreter(make(MeasureVars), make(map[string]*LiteralString))
+
+	// register user funcs
+	// func name is case-sensitive.
+	for _, fn := range fns {
+		if opt, ok := fn.(Option); ok {
+			opt(intrp)
+			continue
+		}
+		if err := intrp.registerUFunc(fn); err != nil {
+			return nil, err
+		}
+	}
+
+	// the vars are parsed after the options, e.g., WithLocale
 	for k, s := range vars {
-		mv, err := makeMeasureValueFromString(s)
+		if !isNumeric(s) {
+			intrp.strvars[k] = makeLiteralString(s)
+			continue
+		}
+		parse := makeMeasureValueFromString
+		if intrp.locale != nil {
+			parse = intrp.locale.ParseMeasureValue
+		}
+		mv, err := parse(s)
 		if err != nil {
 			return nil, err
 		}
-		mvvars[k] = mv
+		intrp.mvvars[k] = mv
 	}
 
+	return intrp, nil
+}
+
+func newInterpreter(mvvars MeasureVars, strvars map[string]*LiteralString) *Interpreter {
 	intrp := Interpreter{
-		mvvars:  mvvars,
-		funcs:   make(map[string]*function),
-		kfuncs:  make(map[string]*function),
-		outvars: make(map[string]*MeasureValue),
+		mvvars:       mvvars,
+		strvars:      strvars,
+		funcs:        make(map[string]*function),
+		kfuncs:       make(map[string]*function),
+		maxCallDepth: defaultMaxCallDepth,
+		modules:      newModuleLoader(),
+		limits:       newLimiter(),
+		fuels:        stdFuels,
+		outvars:      make(map[string]*MeasureValue),
+		outstrs:      make(map[string]string),
 	}
 
 	// register kernel funcs
 	intrp.registerKFuncs()
 
-	// register user funcs
-	// func name is case-sensitive.
-	for _, fn := range fns {
-		if err := intrp.registerUFunc(fn); err != nil {
-			return nil, err
-		}
-	}
-
-	return &intrp, nil
+	return &intrp
 }
 
+// Interpret evaluates the script, the inputs declared by the
+// script are checked against the vars before the evaluation,
+// and the outputs declared are resolved after, see Outputs.
 func (i *Interpreter) Interpret(rd io.Reader) (MeasureVars, error) {
-	r := bufio.NewScanner(rd)
-	for r.Scan() {
-		expr := r.Text()
-		root, err := i.parseOneExpr(expr)
-		if err != nil {
-			return nil, err
-		}
-		if root == nil {
-			continue // empty statement
-		}
-		if err = i.visitRoot(root); err != nil {
-			return nil, err
-		}
-		if i.lastError != nil {
-			return nil, i.lastError
+	return i.InterpretContext(context.Background(), rd)
+}
+
+// InterpretContext is like Interpret, but the evaluation is
+// cancelled once ctx is done, the ctx is also passed to the
+// go funcs taking a context.Context as the first param.
+func (i *Interpreter) InterpretContext(ctx context.Context, rd io.Reader) (MeasureVars, error) {
+	done := i.limits.start(ctx)
+	defer done()
+
+	// the script is parsed as a whole, so that the
+	// inputs are checked before the evaluation.
+	script, err := Parse(rd)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.checkInputs(script.Stmts); err != nil {
+		return nil, err
+	}
+	for _, s := range script.Stmts {
+		if err := i.visitRoot(s.Node); err != nil {
+			return nil, fmt.Errorf("line %d: %w", s.Line, err)
 		}
 	}
+	if err := i.resolveOutputs(script.Stmts); err != nil {
+		return nil, err
+	}
 	return i.outvars, nil
 }
 
+// Vars returns the measure value vars defined so far,
+// including the inputs.
+func (i *Interpreter) Vars() MeasureVars {
+	return i.mvvars
+}
+
+// StrVars returns the string vars defined so far,
+// including the inputs.
+func (i *Interpreter) StrVars() map[string]string {
+	ans := make(map[string]string, len(i.strvars))
+	for k, v := range i.strvars {
+		ans[k] = v.s
+	}
+	return ans
+}
+
+// OutStrings returns the string vars saved by print,
+// the measure value vars are returned by Interpret.
+func (i *Interpreter) OutStrings() map[string]string {
+	return i.outstrs
+}
+
 func (i *Interpreter) registerKFuncs() {
-	fns := []interface{}{i.print}
-	for _, fn := range fns {
-		fi := getFuncInfo(fn)
+	fi := getFuncInfo(i.print)
+	fi.argNames = []string{"vars"}
+	fi.doc = "print saves the given vars to the outputs"
+	fi.byName = true
+	i.kfuncs[fi.funcName] = fi
+
+	kfuncs := []struct {
+		fn       interface{}
+		name     string
+		argNames []string
+		doc      string
+	}{
+		{normalize, "normalize", []string{"v", "temp", "pressure"},
+			"normalize converts the gas volume measured at temp and pressure to Nm3"},
+		{Restate, "restate", []string{"v", "unit"},
+			"restate converts the gas volume between reference conditions, e.g., scf to Nm3"},
+		{GasMass, "gasmass", []string{"v", "molar_mass"},
+			"gasmass converts the gas volume at reference conditions to mass"},
+		{i.energy, "energy", []string{"fuel_use", "fuel"},
+			"energy converts the fuel use to energy by the NCV of the fuel"},
+		{i.mass, "mass", []string{"fuel_use", "fuel"},
+			"mass converts the fuel use to mass by the NCV or density of the fuel"},
+		{i.volume, "volume", []string{"fuel_use", "fuel"},
+			"volume converts the fuel use to volume by the NCV and density of the fuel"},
+		{i.exchange, "exchange", []string{"v", "currency", "date"},
+			"exchange converts the currency by the rate on the date, e.g., 2023-06-30"},
+		{i.inflate, "inflate", []string{"v", "from_year", "to_year"},
+			"inflate adjusts the currency from the price level of a year to another"},
+	}
+	for _, kf := range kfuncs {
+		fi := getFuncInfo(kf.fn)
+		fi.funcName, fi.argNames, fi.doc = kf.name, kf.argNames, kf.doc
+		fi.shadowable = true
 		i.kfuncs[fi.funcName] = fi
 	}
 }
 
-// registerUFunc register expr functions
-// add func check to make sure
+// registerUFunc register expr functions named
+// after the go func name.
+func (i *Interpreter) registerUFunc(fn interface{}) error {
+	if !isFunc(fn) {
+		return fmt.Errorf("expect func, got %T", fn)
+	}
+	return i.registerFunc(getFuncInfo(fn))
+}
+
+// registerFunc add func check to make sure
 // the func with the following return
 // signature:
 //  1. no return: func(....)
 //  2. one return with *MeasureValue: func(....) *MeasureValue
 //  3. two return with *MeasureValue and an error: func(....) (*MeasureValue, error)
-func (i *Interpreter) registerUFunc(fn interface{}) error {
-	fi := getFuncInfo(fn)
-	if _, ok := i.kfuncs[fi.funcName]; ok {
+func (i *Interpreter) registerFunc(fi *function) error {
+	if kf, ok := i.kfuncs[fi.funcName]; ok && !kf.shadowable {
 		return fmt.Errorf("overwriting kernel func %v not allowed", fi.funcName)
 	}
 	if _, ok := i.funcs[fi.funcName]; ok {
@@ -131,15 +263,38 @@ func (i *Interpreter) registerUFunc(fn interface{}) error {
 	return nil
 }
 
-func (i *Interpreter) parseOneExpr(expr string) (Node, error) {
+// Check parses the script without evaluating it,
+// the first syntax error found is returned.
+func Check(rd io.Reader) error {
+	_, err := Parse(rd)
+	return err
+}
+
+// SyntaxError reports the statement can not be parsed
+type SyntaxError struct {
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+func parseOneExpr(expr string) (Node, error) {
 	l := newLexer(expr)
 	if ret := exprParse(l); ret != 0 {
-		return nil, l.lastError
+		return nil, &SyntaxError{Err: l.lastError}
 	}
 	return l.root, nil
 }
 
 func (i *Interpreter) visitRoot(root Node) error {
+	if err := i.limits.step(root); err != nil {
+		return err
+	}
 	switch root.Type() {
 	case NodeTypeAssignment:
 		if err := i.visitAssignment(root.(*Assignment)); err != nil {
@@ -150,53 +305,151 @@ func (i *Interpreter) visitRoot(root Node) error {
 		if _, err := i.visitFuncCall(root.(*FuncCall)); err != nil {
 			return err
 		}
+	case NodeTypeFuncDef:
+		if err := i.visitFuncDef(root.(*FuncDef)); err != nil {
+			return err
+		}
+	case NodeTypeImport:
+		if err := i.visitImport(root.(*Import)); err != nil {
+			return err
+		}
+	case NodeTypeInput:
+		// the inputs are checked before the evaluation
+	case NodeTypeOutput:
+		// the outputs are resolved after the evaluation
 	}
 	return nil
 }
 
+// visitFuncDef registers the script func as a user func,
+// so that it is called the same way as a go func.
+func (i *Interpreter) visitFuncDef(a *FuncDef) error {
+	if kf, ok := i.kfuncs[a.name]; ok && !kf.shadowable {
+		return fmt.Errorf("overwriting kernel func %v not allowed", a.name)
+	}
+	// redefine a script func is allowed,
+	// but not the go func.
+	if f, ok := i.funcs[a.name]; ok && f.def == nil {
+		return fmt.Errorf("found reregistered func %s", a.name)
+	}
+	i.funcs[a.name] = i.makeScriptFunc(a)
+	return nil
+}
+
+func (i *Interpreter) makeScriptFunc(def *FuncDef) *function {
+	fn := func(args ...interface{}) (Node, error) {
+		if len(i.frames) >= i.maxCallDepth {
+			return nil, fmt.Errorf("func %s exceeds max call depth %d", def.name, i.maxCallDepth)
+		}
+		// the calls fan out, e.g., f(a) = g(a) + g(a),
+		// so the bodies are counted like the statements.
+		if err := i.limits.step(def.body); err != nil {
+			return nil, err
+		}
+		frame := make(map[string]Node, len(args))
+		for k, arg := range args {
+			switch a := arg.(type) {
+			case string:
+				frame[def.params[k]] = makeLiteralString(a)
+			case *MeasureValue:
+				if a == nil {
+					return nil, fmt.Errorf("found undefined arg %s of func %s", def.params[k], def.name)
+				}
+				frame[def.params[k]] = a
+			default:
+				return nil, fmt.Errorf("unsupported arg %s of func %s: %T", def.params[k], def.name, a)
+			}
+		}
+		i.frames = append(i.frames, frame)
+		defer func() { i.frames = i.frames[:len(i.frames)-1] }()
+		return i.visitAExpr(def.body)
+	}
+	fi := getFuncInfo(fn)
+	fi.funcName = def.name
+	fi.argNames = def.params
+	fi.def = def
+	return fi
+}
+
 func (i *Interpreter) visitAssignment(a *Assignment) error {
 	switch a.node.Type() {
 	case NodeTypeFuncCall:
-		mv, err := i.visitFuncCall(a.node.(*FuncCall))
+		ans, err := i.visitFuncCall(a.node.(*FuncCall))
 		if err != nil {
 			return err
 		}
 		// we are expecting func call
-		// returning either mv or void
-		// so if it returns valid mv,
-		// assign the mv to the var,
+		// returning either value or void
+		// so if it returns valid value,
+		// assign the value to the var,
 		// otherwise ignore it.
-		if mv != nil {
-			i.mvvars[a.variable] = mv
+		if ans != nil {
+			i.setVar(a.variable, ans)
 		}
 	default:
 		ans, err := i.visitAExpr(a.node)
 		if err != nil {
 			return err
 		}
-		i.mvvars[a.variable] = ans
+		i.setVar(a.variable, ans)
 	}
 	return nil
 }
 
+// setVar binds the value to the var name, a var
+// holds either a *MeasureValue or a *LiteralString.
+func (i *Interpreter) setVar(name string, value Node) {
+	switch v := value.(type) {
+	case *MeasureValue:
+		delete(i.strvars, name)
+		i.mvvars[name] = v
+	case *LiteralString:
+		delete(i.mvvars, name)
+		i.strvars[name] = v
+	}
+}
+
+// lookupVar returns the value of the var name, either
+// a *MeasureValue or a *LiteralString. The params of
+// the script func being called shadow the global vars.
+func (i *Interpreter) lookupVar(name string) (Node, bool) {
+	if n := len(i.frames); n > 0 {
+		if v, ok := i.frames[n-1][name]; ok {
+			return v, true
+		}
+	}
+	if mv, ok := i.mvvars[name]; ok {
+		return mv, true
+	}
+	if str, ok := i.strvars[name]; ok {
+		return str, true
+	}
+	return nil, false
+}
+
 // visitAExpr visits expr node, return either *MeasureValue
-// or decimal.Decimal
-func (i *Interpreter) visitAExpr(a Node) (*MeasureValue, error) {
+// or *LiteralString
+func (i *Interpreter) visitAExpr(a Node) (Node, error) {
 	switch a.Type() {
 	case NodeTypeMV:
 		mv := i.visitMeasuredValue(a.(*MeasureValue))
 		return mv, nil
+	case NodeTypeLiteralStr:
+		return a, nil
 	case NodeTypeVar:
 		var_ := i.visitVariable(a.(*Variable))
-		if value, ok := i.mvvars[var_.Name]; ok {
+		if value, ok := i.lookupVar(var_.Name); ok {
 			return value, nil
 		}
-		return nil, fmt.Errorf("found undefined var %s" + var_.Name)
+		return nil, fmt.Errorf("found undefined var %s", var_.Name)
 	case NodeTypeBinaryExpr:
 		mv, err := i.visitBinaryExpr(a.(*BinaryExpr))
 		if err != nil {
 			return nil, err
 		}
+		if err := i.limits.checkDigits(mv); err != nil {
+			return nil, err
+		}
 		return mv, nil
 	case NodeTypeUnaryExpr:
 		mv, err := i.visitUnaryExpr(a.(*UnaryExpr))
@@ -210,23 +463,41 @@ func (i *Interpreter) visitAExpr(a Node) (*MeasureValue, error) {
 			return nil, err
 		}
 		return mv, nil
+	case NodeTypeFuncCall:
+		fc := a.(*FuncCall)
+		ans, err := i.visitFuncCall(fc)
+		if err != nil {
+			return nil, err
+		}
+		if ans == nil {
+			return nil, fmt.Errorf("func %s returns no value", fc.fn)
+		}
+		return ans, nil
 	default:
 		return nil, fmt.Errorf("found unsupported expr node: %v", a.Type())
 	}
 }
 
-func (i *Interpreter) visitFuncCall(a *FuncCall) (*MeasureValue, error) {
-	if kf, ok := i.kfuncs[a.fn]; ok {
+func (i *Interpreter) visitFuncCall(a *FuncCall) (Node, error) {
+	if err := i.limits.checkCtx(); err != nil {
+		return nil, err
+	}
+	_, shadowed := i.funcs[a.fn]
+	if kf, ok := i.kfuncs[a.fn]; ok && !(kf.shadowable && shadowed) {
 		// we have a kernel func call
+		visitArg := i.visitFuncArg
+		if kf.byName {
+			visitArg = i.visitKFuncArg
+		}
 		var args []interface{}
 		for _, argnode := range a.args {
-			arg, err := i.visitKFuncArg(argnode)
+			arg, err := visitArg(argnode)
 			if err != nil {
 				return nil, err
 			}
 			args = append(args, arg)
 		}
-		return i.call(kf, args...)
+		return i.call(a, kf, args...)
 	}
 	// we have a user func call
 	f, ok := i.funcs[a.fn]
@@ -241,7 +512,7 @@ func (i *Interpreter) visitFuncCall(a *FuncCall) (*MeasureValue, error) {
 		}
 		args = append(args, arg)
 	}
-	return i.call(f, args...)
+	return i.call(a, f, args...)
 }
 
 func (i *Interpreter) visitKFuncArg(a Node) (interface{}, error) {
@@ -255,7 +526,7 @@ func (i *Interpreter) visitKFuncArg(a Node) (interface{}, error) {
 		// case is the print func.
 		return a, nil
 	default:
-		return i.visitAExpr(a)
+		return i.visitFuncArgValue(a)
 	}
 }
 
@@ -266,32 +537,57 @@ func (i *Interpreter) visitFuncArg(a Node) (interface{}, error) {
 		return str, nil
 	case NodeTypeVar:
 		varname := a.(*Variable).Name
-		return i.mvvars[varname], nil
+		value, _ := i.lookupVar(varname)
+		if str, ok := value.(*LiteralString); ok {
+			return i.visitLiteralStr(str), nil
+		}
+		// an undefined var is passed as nil *MeasureValue
+		mv, _ := value.(*MeasureValue)
+		return mv, nil
 	default:
-		return i.visitAExpr(a)
+		return i.visitFuncArgValue(a)
 	}
 }
 
-func (i *Interpreter) call(f *function, args ...interface{}) (*MeasureValue, error) {
+// visitFuncArgValue evaluates the func arg expr, string
+// values are passed to funcs as plain go string.
+func (i *Interpreter) visitFuncArgValue(a Node) (interface{}, error) {
+	ans, err := i.visitAExpr(a)
+	if err != nil {
+		return nil, err
+	}
+	if str, ok := ans.(*LiteralString); ok {
+		return i.visitLiteralStr(str), nil
+	}
+	return ans, nil
+}
+
+// call calls the func, a panic of the func is returned as the error
+func (i *Interpreter) call(a *FuncCall, f *function, args ...interface{}) (ans Node, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err, ok := r.(error)
+			rerr, ok := r.(error)
 			if !ok {
-				err = errors.New(fmt.Sprint(r))
+				rerr = errors.New(fmt.Sprint(r))
 			}
-			i.lastError = fmt.Errorf("call func %s failed: %v", f.funcName, err)
+			ans, err = nil, fmt.Errorf("call func %s at col %d failed: %v", a.fn, a.pos, rerr)
 		}
 	}()
 
 	// assuming the f is valid if we
 	// can get it from the funcs pool.
-	var rargs []reflect.Value
-	for _, arg := range args {
-		rargs = append(rargs, reflect.ValueOf(arg))
+	rargs, err := f.coerceArgs(i.limits.ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("call func %s at col %d: %v", a.fn, a.pos, err)
 	}
 
 	results, err := f.call(rargs...)
 	if err != nil {
+		// the func might fail because of the cancellation,
+		// report the cancellation instead.
+		if cerr := i.limits.checkCtx(); cerr != nil {
+			return nil, cerr
+		}
 		return nil, err
 	}
 	switch len(results) {
@@ -299,13 +595,25 @@ func (i *Interpreter) call(f *function, args ...interface{}) (*MeasureValue, err
 		return nil, nil
 	case 1, 2:
 		// if the result has one or two results
-		// it is guaranteed that it is *MeasureValue
-		val := results[0].Interface()
-		if val == nil {
+		// it is guaranteed that it is *MeasureValue,
+		// or a Node for the script funcs.
+		switch val := results[0].Interface().(type) {
+		case *MeasureValue:
+			if val == nil {
+				return nil, nil
+			}
+			if err := i.limits.checkDigits(val); err != nil {
+				return nil, err
+			}
+			return val, nil
+		case *LiteralString:
+			if val == nil {
+				return nil, nil
+			}
+			return val, nil
+		default:
 			return nil, nil
 		}
-		mv := results[0].Interface().(*MeasureValue)
-		return mv, nil
 	default:
 		return nil, fmt.Errorf("unexpected number of func call results, expected at most 2, got %d", len(results))
 	}
@@ -323,7 +631,7 @@ func (i *Interpreter) visitVariable(a *Variable) *Variable {
 	return a
 }
 
-func (i *Interpreter) visitBinaryExpr(a *BinaryExpr) (*MeasureValue, error) {
+func (i *Interpreter) visitBinaryExpr(a *BinaryExpr) (Node, error) {
 	lhs, err := i.visitAExpr(a.lhs)
 	if err != nil {
 		return nil, err
@@ -333,28 +641,97 @@ func (i *Interpreter) visitBinaryExpr(a *BinaryExpr) (*MeasureValue, error) {
 		return nil, err
 	}
 	switch a.Op {
+	case OpEq, OpNe:
+		eq, err := i.equal(lhs, rhs)
+		if err != nil {
+			return nil, err
+		}
+		return makeUnitlessBool(eq == (a.Op == OpEq)), nil
+	}
+	lstr, lok := lhs.(*LiteralString)
+	rstr, rok := rhs.(*LiteralString)
+	if lok && rok && a.Op == OpAdd {
+		// string concatenation
+		return makeLiteralString(lstr.s + rstr.s), nil
+	}
+	lmv, lok := lhs.(*MeasureValue)
+	rmv, rok := rhs.(*MeasureValue)
+	if !lok || !rok {
+		return nil, fmt.Errorf("(%s)%s(%s) is unsupported", kindOf(lhs), a.Op, kindOf(rhs))
+	}
+	switch a.Op {
 	case OpAdd:
-		return lhs.Add(rhs)
+		return lmv.Add(rmv)
 	case OpSub:
-		return lhs.Sub(rhs)
+		return lmv.Sub(rmv)
 	case OpMul:
-		return lhs.Mul(rhs)
+		return lmv.Mul(rmv)
 	case OpDiv:
-		return lhs.Div(rhs)
+		return lmv.Div(rmv)
 	default:
 		return nil, fmt.Errorf("unsupported op %s", a.Op)
 	}
 }
 
+// equal compares two values, strings are compared
+// literally, measure values are compared in si.
+func (i *Interpreter) equal(lhs, rhs Node) (bool, error) {
+	switch l := lhs.(type) {
+	case *LiteralString:
+		r, ok := rhs.(*LiteralString)
+		if !ok {
+			break
+		}
+		return l.s == r.s, nil
+	case *MeasureValue:
+		r, ok := rhs.(*MeasureValue)
+		if !ok {
+			break
+		}
+		c, err := l.Cmp(r)
+		if err != nil {
+			return false, fmt.Errorf("(%s)==(%s) is unsupported", l.unit, r.unit)
+		}
+		return c == 0, nil
+	}
+	return false, fmt.Errorf("(%s)==(%s) is unsupported", kindOf(lhs), kindOf(rhs))
+}
+
+// kindOf describes the value kind for error messages.
+func kindOf(n Node) string {
+	switch v := n.(type) {
+	case *LiteralString:
+		return "string"
+	case *MeasureValue:
+		return v.unit
+	default:
+		return fmt.Sprintf("%T", n)
+	}
+}
+
 func (i *Interpreter) visitUnaryExpr(a *UnaryExpr) (*MeasureValue, error) {
 	ans, err := i.visitAExpr(a.expr)
 	if err != nil {
 		return nil, err
 	}
-	return ans.Neg(), nil
+	mv, ok := ans.(*MeasureValue)
+	if !ok {
+		if a.Op == OpPct {
+			return nil, fmt.Errorf("(%s)%% is unsupported", kindOf(ans))
+		}
+		return nil, fmt.Errorf("%s(%s) is unsupported", a.Op, kindOf(ans))
+	}
+	switch a.Op {
+	case OpSub:
+		return mv.Neg(), nil
+	case OpPct:
+		return mv.Percent(), nil
+	default:
+		return mv.withValue(mv.value), nil
+	}
 }
 
-func (i *Interpreter) visitParenExpr(a *ParenExpr) (*MeasureValue, error) {
+func (i *Interpreter) visitParenExpr(a *ParenExpr) (Node, error) {
 	ans, err := i.visitAExpr(a.expr)
 	if err != nil {
 		return nil, err
@@ -365,21 +742,25 @@ func (i *Interpreter) visitParenExpr(a *ParenExpr) (*MeasureValue, error) {
 // print is the kernel func of the expr
 // it will save the given name of the varname
 // to outvars, the given varname should be
-// MeasureValue var only, any non-MeasureValue
-// var will cause error.
-func (i *Interpreter) print(args ...interface{}) {
+// a defined var only, any undefined var or
+// non-var arg will cause error.
+func (i *Interpreter) print(args ...interface{}) error {
 	for _, arg := range args {
-		switch a := arg.(type) {
-		case *Variable:
-			value, ok := i.mvvars[a.Name]
-			if !ok {
-				continue
-			}
-			i.outvars[a.Name] = value
-		default:
-			i.lastError = fmt.Errorf("expect variable as the arg of print, found: %T", a)
+		a, ok := arg.(*Variable)
+		if !ok {
+			return fmt.Errorf("expect variable as the arg of print, found: %T", arg)
+		}
+		if str, ok := i.strvars[a.Name]; ok {
+			i.outstrs[a.Name] = str.s
+			continue
 		}
+		value, ok := i.mvvars[a.Name]
+		if !ok {
+			return fmt.Errorf("found undefined var %s", a.Name)
+		}
+		i.outvars[a.Name] = value
 	}
+	return nil
 }
 
 type function struct {
@@ -390,6 +771,21 @@ type function struct {
 	errorIndexes    []int
 	fnValue         reflect.Value
 	funcName        string
+	withCtx         bool
+
+	argNames []string
+	doc      string
+
+	// byName passes the var args of the kernel
+	// func by name instead of value, e.g., print.
+	byName bool
+	// shadowable kernel funcs are shadowed by the user
+	// and script funcs of the same name, e.g., energy.
+	shadowable bool
+
+	// def is the definition of script func,
+	// nil for the go func.
+	def *FuncDef
 }
 
 func getFuncInfo(fn interface{}) *function {
@@ -414,8 +810,14 @@ func getFuncInfo(fn interface{}) *function {
 	var returnTypeNames []string
 	var returnTypes []reflect.Type
 
-	// Extract parameter information
-	for i := 0; i < fnType.NumIn(); i++ {
+	// Extract parameter information, a context.Context as the
+	// first param is passed by the interpreter, not the script.
+	withCtx := fnType.NumIn() > 0 && fnType.In(0) == contextType
+	start := 0
+	if withCtx {
+		start = 1
+	}
+	for i := start; i < fnType.NumIn(); i++ {
 		it := fnType.In(i)
 		paramType := it
 		paramName := it.Name()
@@ -450,6 +852,7 @@ func getFuncInfo(fn interface{}) *function {
 		errorIndexes:    errorIndexes,
 		fnValue:         fnValue,
 		funcName:        funcName,
+		withCtx:         withCtx,
 	}
 }
 
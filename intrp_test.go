@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -101,6 +102,47 @@ print(CH4, GHG, a);
 	}
 }
 
+func TestInterpreterUnary(t *testing.T) {
+	exprs := `
+neg = -CO2;
+pos = +CO2;
+sum = CO2 + -CO2;
+dbl = (-CO2) * 2;
+oxidation = 99.5%;
+CO2ox = CO2 * oxidation;
+part = 5kg%;
+b = 2 * -3 + 10%;
+c = -(1t) + 1kg;
+print(neg, pos, sum, dbl, oxidation, CO2ox, part, b, c);
+`
+	vars := map[string]string{"CO2": "110kg"}
+	intrp, err := NewInterpreter(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewBufferString(exprs)
+	outvars, err := intrp.Interpret(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gots []string
+	for _, name := range []string{"neg", "pos", "sum", "dbl", "oxidation", "CO2ox", "part", "b", "c"} {
+		gots = append(gots, outvars[name].String())
+	}
+	expected := []string{"-110kg", "110kg", "0kg", "-220kg", "0.995", "109.45kg", "0.05kg", "-5.9", "-999kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+	// the unit is kept through the later conversion
+	neg, err := outvars["neg"].To("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if neg.String() != "-0.11t" {
+		t.Fatalf("expected -0.11t, got %v", neg)
+	}
+}
+
 func TestInterpreterReuseVar(t *testing.T) {
 	exprs := `
 a = a + 1kg;
@@ -128,6 +170,29 @@ print(a, b);
 	}
 }
 
+func fPanic(a *MeasureValue) *MeasureValue {
+	panic("boom")
+}
+
+func TestInterpreterFuncPanic(t *testing.T) {
+	intrp, err := NewInterpreter(map[string]string{"a": "1kg"}, fPanic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = intrp.Interpret(bytes.NewBufferString("b = a * 2;\nc = fPanic(a);"))
+	if err == nil || !strings.Contains(err.Error(), "line 2: call func fPanic at col 5 failed: boom") {
+		t.Fatalf("expected the panic of fPanic, got %v", err)
+	}
+	// the panic is not kept by the interpreter
+	outvars, err := intrp.Interpret(bytes.NewBufferString("d = a + 1kg;\nprint(d);"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outvars["d"].String(); got != "2kg" {
+		t.Fatalf("expected 2kg, got %s", got)
+	}
+}
+
 func TestInterpreterSyntaxError(t *testing.T) {
 	cases := []struct {
 		expr string
@@ -211,6 +276,8 @@ func TestInterpreterFuncs(t *testing.T) {
 		{expr: `mTypesF("hello world", a, 1kg, "1kg", "10(10^3m3)");`, ok: true, hint: `"hello world" is pass as str to func(because it a LITERALSTR token`},
 		{expr: `fAny("1kg", a);`, ok: true},
 		{expr: `fAny();`, ok: false, hint: "error raise by func"},
+		{expr: "print(a, x);", ok: false, hint: "undefined var x"},
+		{expr: "print(1kg);", ok: false, hint: "not a var"},
 	}
 	vars := map[string]string{"a": "1kg", "b": "1(10^3m3)"}
 	for i, c := range cases {
@@ -236,3 +303,202 @@ func TestInterpreterFuncs(t *testing.T) {
 		})
 	}
 }
+
+func fFactor(fuel string) *MeasureValue {
+	if fuel == "diesel" {
+		return mustMV("74.1kg/Gj", false)
+	}
+	return mustMV("69.3kg/Gj", false)
+}
+
+func TestInterpreterStrings(t *testing.T) {
+	exprs := `
+kind = "die" + "sel";
+same = fuel == kind;
+diff = fuel != "petrol";
+factor = (fuel == "diesel") * 74.1kg/Gj + (fuel == "petrol") * 69.3kg/Gj;
+CO2 = activity_value * factor;
+eq = 1000kg == 1t;
+unit = "kg";
+print(kind, fuel, same, diff, CO2, eq, unit);
+`
+	vars := map[string]string{
+		"fuel":           "diesel",
+		"activity_value": "2Gj",
+	}
+	intrp, err := NewInterpreter(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewBufferString(exprs)
+	outvars, err := intrp.Interpret(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outstrs := intrp.OutStrings()
+	gots := []string{outstrs["kind"], outstrs["fuel"], outstrs["unit"],
+		outvars["same"].String(), outvars["diff"].String(), outvars["CO2"].String(), outvars["eq"].String()}
+	expected := []string{"diesel", "diesel", "kg", "1", "1", "148.2kg", "1"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+}
+
+func TestInterpreterInputVars(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		str      bool
+		fail     bool
+	}{
+		{input: "1kg", expected: "1kg"},
+		{input: " -2t", expected: "-2t"},
+		{input: "+.5kg", expected: "0.5kg"},
+		{input: "-.5", expected: "-0.5"},
+		{input: "diesel", expected: "diesel", str: true},
+		{input: "-abc", expected: "-abc", str: true},
+		{input: ".net", expected: ".net", str: true},
+		{input: "+", expected: "+", str: true},
+		{input: "", expected: "", str: true},
+		{input: "1kgx", fail: true},
+		{input: "-1 abc", fail: true},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(map[string]string{"a": c.input})
+			if c.fail {
+				if err == nil {
+					t.Fatalf("expected err of %q", c.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if c.str {
+				got = intrp.StrVars()["a"]
+			} else {
+				got = intrp.Vars()["a"].String()
+			}
+			if got != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestInterpreterStringErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		ok   bool
+		hint string
+	}{
+		{expr: `a = fFactor(fuel);`, ok: true, hint: "string var is passed as go string"},
+		{expr: `a = fFactor("petrol");`, ok: true},
+		{expr: `a = fuel + 1kg;`, ok: false, hint: "string + measure value"},
+		{expr: `a = fuel * fuel;`, ok: false, hint: "only + is allowed on strings"},
+		{expr: `a = -fuel;`, ok: false},
+		{expr: `a = +fuel;`, ok: false},
+		{expr: `a = fuel%;`, ok: false},
+		{expr: `a = fuel == 1kg;`, ok: false, hint: "compare string with measure value"},
+		{expr: `a = 1kg == 1m3;`, ok: false, hint: "compare different dimensions"},
+		{expr: `a = kg;`, ok: false, hint: "unquoted unit is not a string"},
+	}
+	vars := map[string]string{"fuel": "diesel"}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(vars, fFactor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd := bytes.NewBufferString(c.expr)
+			_, err = intrp.Interpret(rd)
+			switch c.ok {
+			case false:
+				if err == nil {
+					t.Fatalf("expected err, got nil")
+				}
+				t.Log(err)
+			case true:
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestInterpreterScriptFuncs(t *testing.T) {
+	exprs := `
+func emission(activity, factor, ox) = activity * factor * ox;
+func co2(activity) = emission(activity, CO2Factor, 0.98);
+func label(fuel) = fuel + " combustion";
+func twice(a) = fInt(a) + a;
+CO2 = co2(activity_value);
+CH4 = emission(activity_value, 7.2E-06Gg/10^3m3, 1) + 1kg;
+name = label("diesel");
+b = twice(2kg);
+activity = 3(10^3m3);
+c = co2(1(10^3m3));
+print(CO2, CH4, name, b, activity, c);
+`
+	vars := map[string]string{
+		"activity_value": "1(10^3m3)",
+		"CO2Factor":      "1.1E-04Gg/10^3m3",
+	}
+	intrp, err := NewInterpreter(vars, fInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewBufferString(exprs)
+	outvars, err := intrp.Interpret(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["CO2"].String(), outvars["CH4"].String(), intrp.OutStrings()["name"],
+		outvars["b"].String(), outvars["activity"].String(), outvars["c"].String()}
+	expected := []string{"107.8kg", "8.2kg", "diesel combustion", "4kg", "3(10^3m3)", "107.8kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+}
+
+func TestInterpreterScriptFuncErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		ok   bool
+		hint string
+	}{
+		{expr: "func f(a) = a * 2;\nfunc f(a) = a * 3;", ok: true, hint: "redefine script func"},
+		{expr: "func f(a) = f(a);\na = f(1);", ok: false, hint: "exceeds max call depth"},
+		{expr: "func f(a) = a;\na = f(1, 2);", ok: false, hint: "wrong number of args"},
+		{expr: "func f(a) = b;\na = f(1);", ok: false, hint: "params are the only local vars"},
+		{expr: "func f(a) = a;\na = f(b);", ok: false, hint: "undefined arg"},
+		{expr: "func f(a, a) = a;", ok: false, hint: "duplicate param"},
+		{expr: "func print(a) = a;", ok: false, hint: "overwriting kernel func"},
+		{expr: "func fInt(a) = a;", ok: false, hint: "overwriting go func"},
+		{expr: "a = print(a) + 1;", ok: false, hint: "void func in expr"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(nil, fInt, WithMaxCallDepth(8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd := bytes.NewBufferString(c.expr)
+			_, err = intrp.Interpret(rd)
+			switch c.ok {
+			case false:
+				if err == nil {
+					t.Fatalf("expected err, got nil")
+				}
+				t.Log(err)
+			case true:
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -15,12 +16,33 @@ const (
 
 var (
 	rules = []rule{
-		{regexp.MustCompile("^[_a-zA-Z][_a-zA-Z0-9]*"), IDENT},
+		// an ident might be namespaced by the imported module, e.g., fuels.diesel_ncv
+		{regexp.MustCompile("^[_a-zA-Z][_a-zA-Z0-9]*(\\.[_a-zA-Z][_a-zA-Z0-9]*)*"), IDENT},
 		{regexp.MustCompile("^[0-9]*\\.?[0-9]+([eE][-+]?[0-9]+)?"), NUM},
 		{regexp.MustCompile(`^"[^"]*"`), LITERALSTR},
+		{regexp.MustCompile("^=="), EQ},
+		{regexp.MustCompile("^!="), NE},
 	}
 )
 
+// keywords are reserved idents
+var keywords = map[string]int{
+	"func":   FUNC,
+	"import": IMPORT,
+	"input":  INPUT,
+	"output": OUTPUT,
+}
+
+// declKeywords are reserved in the input and output declarations
+// only, e.g., the default of input a: Mass default 1kg;
+var declKeywords = map[string]int{
+	"default": DEFAULT,
+	"range":   RANGE,
+	"in":      IN,
+	"as":      AS,
+	"desc":    DESC,
+}
+
 type rule struct {
 	re    *regexp.Regexp
 	token int
@@ -28,7 +50,15 @@ type rule struct {
 
 type lexer struct {
 	in    string
+	size  int
 	rules []rule
+	// prev is the previous token
+	prev int
+	// decl is whether the input declaration is being lexed
+	decl bool
+	// spaced is whether the spaces multiply the units, i.e.,
+	// the measure value strings, e.g., "5 kg m-3".
+	spaced bool
 
 	um UnitManager
 
@@ -39,12 +69,19 @@ type lexer struct {
 func newLexer(expr string) *lexer {
 	return &lexer{
 		in:    expr,
+		size:  len(expr),
 		rules: rules,
 		um:    StdUm,
 	}
 }
 
 func (l *lexer) Lex(lval *exprSymType) int {
+	tok := l.lex(lval)
+	l.prev = tok
+	return tok
+}
+
+func (l *lexer) lex(lval *exprSymType) int {
 	// Skip spaces.
 	for len(l.in) > 0 && isSpace(l.in[0]) {
 		l.in = l.in[1:]
@@ -56,15 +93,16 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return eof
 	}
 
-	// Try math on unit first
-	if n, ok := l.um.Peek(l.in); ok {
+	// 1-based column of the token
+	lval.pos = l.size - len(l.in) + 1
+
+	// Try math on unit first, a unit only follows a number or
+	// the in of the output declarations, otherwise the var like
+	// `t` would be taken as the unit.
+	if n, ok := l.peekUnit(); ok {
 		str := l.in[:n]
 		l.in = l.in[n:]
-		if str[0] == '(' {
-			// remove brackets
-			str = str[1 : len(str)-1]
-		}
-		lval.str = str
+		lval.str = canonicalUnit(l.um, str)
 		lval.token = UNIT
 		return UNIT
 	}
@@ -76,11 +114,27 @@ func (l *lexer) Lex(lval *exprSymType) int {
 			switch r.token {
 			case IDENT:
 				lval.str = str
-			case NUM:
+				if tok, ok := keywords[str]; ok {
+					l.decl = tok == INPUT || tok == OUTPUT
+					lval.token = tok
+					return tok
+				}
+				// the declared name is not a keyword, e.g., output desc;
+				if tok, ok := declKeywords[str]; ok && l.decl && l.prev != INPUT && l.prev != OUTPUT {
+					lval.token = tok
+					return tok
+				}
+			case NUM, EQ, NE:
 				lval.str = str
 			case LITERALSTR:
 				// remove quote
 				str = str[1 : len(str)-1]
+				// the label and the description are strings
+				// as is, e.g., output x in MWh as "kWh";
+				if l.prev == AS || l.prev == DESC {
+					lval.str = str
+					break
+				}
 				r.token = l.lexLiteralStr(str, lval)
 			}
 			lval.token = r.token
@@ -95,9 +149,24 @@ func (l *lexer) Lex(lval *exprSymType) int {
 	return ret
 }
 
+// peekUnit peeks the unit after a number or the in of the output
+// declarations. Inline of an expression, the spaces do not multiply
+// the units, i.e., the `t` of 2kg t is not of the unit, and the
+// keywords of the declarations are separators of the declarations.
+func (l *lexer) peekUnit() (int, bool) {
+	if l.prev != NUM && l.prev != IN {
+		return 0, false
+	}
+	if p, ok := l.um.(inlinePeeker); ok && !l.spaced {
+		return p.peekInline(l.in, l.decl)
+	}
+	return l.um.Peek(l.in)
+}
+
 func (l *lexer) lexLiteralStr(s string, lval *exprSymType) int {
 	if ok := l.um.IsUnit(s); ok {
-		lval.str = s
+		lval.str = canonicalUnit(l.um, s)
+		lval.quoted = true
 		return UNIT
 	}
 	if _, err := NewMeasureValueFromString(s); err == nil {
@@ -124,6 +193,19 @@ func isSpace(c byte) bool {
 	return c == ' ' || c == '\t' || c == '\n'
 }
 
+// isNumeric check if s starts like a number, i.e., a digit
+// after an optional sign and dot, e.g., 1, -1, .5 or -.5.
+func isNumeric(s string) bool {
+	s = strings.TrimLeft(s, " \t\n")
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	if len(s) > 0 && s[0] == '.' {
+		s = s[1:]
+	}
+	return len(s) > 0 && s[0] >= '0' && s[0] <= '9'
+}
+
 func startWithSeparator(s string) bool {
 	for len(s) > 0 && isSpace(s[0]) {
 		s = s[1:]
@@ -131,12 +213,37 @@ func startWithSeparator(s string) bool {
 	if len(s) == 0 {
 		return true
 	}
+	// comparison operators are separators,
+	// but a single '=' is not, e.g., the `m`
+	// in `m = 1` is a variable, not a unit.
+	if strings.HasPrefix(s, "==") || strings.HasPrefix(s, "!=") {
+		return true
+	}
 	c := s[0]
-	return c == ',' || c == ';' || c == '(' || c == ')' || c == '+' || c == '-' || c == '*' || c == '/'
+	return c == ',' || c == ';' || c == '(' || c == ')' || c == ']' || c == '+' || c == '-' || c == '*' || c == '/' || c == '%'
+}
+
+// startWithDeclKeyword check if s starts with a keyword of the
+// declarations, e.g., the range of `default 1kg range [0, 1e9]`.
+func startWithDeclKeyword(s string) bool {
+	s = strings.TrimLeft(s, " \t\n")
+	for kw := range declKeywords {
+		if rest, ok := strings.CutPrefix(s, kw); ok && (rest == "" || isSpace(rest[0])) {
+			return true
+		}
+	}
+	return false
 }
 
 func NewMeasureValueFromString(s string) (*MeasureValue, error) {
-	l := newLexer(s)
+	// the sign is not part of the NUM token
+	in := strings.TrimLeft(s, " \t\n")
+	neg := strings.HasPrefix(in, "-")
+	if neg || strings.HasPrefix(in, "+") {
+		in = in[1:]
+	}
+	l := newLexer(in)
+	l.spaced = true
 	var lvals []exprSymType
 	for {
 		var lval exprSymType
@@ -144,14 +251,26 @@ func NewMeasureValueFromString(s string) (*MeasureValue, error) {
 		if ret == eof {
 			break
 		}
-		if lval.token != invalid {
-			lvals = append(lvals, lval)
+		if lval.token == invalid {
+			// brackets around the unit are allowed, e.g., 1(Gg/10^3m3)
+			if ret == '(' || ret == ')' {
+				// the unit in brackets follows the number
+				if len(lvals) > 0 {
+					l.prev = lvals[len(lvals)-1].token
+				}
+				continue
+			}
+			return nil, fmt.Errorf("invalid measure value: %s", s)
 		}
+		lvals = append(lvals, lval)
 	}
-	if len(lvals) != 2 || (lvals[0].token != NUM && lvals[1].token != UNIT) {
+	if len(lvals) != 2 || lvals[0].token != NUM || lvals[1].token != UNIT {
 		return nil, fmt.Errorf("invalid measure value: %s", s)
 	}
 	num, unit := lvals[0].str, lvals[1].str
 	d, _ := decimal.NewFromString(num)
+	if neg {
+		d = d.Neg()
+	}
 	return &MeasureValue{um: StdUm, unit: unit, value: d}, nil
 }
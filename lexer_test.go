@@ -93,6 +93,19 @@ func TestLexer(t *testing.T) {
 			expr:     `a * b * (1 + 2);`,
 			expected: []int{IDENT, IDENT, NUM, NUM},
 		},
+		{
+			expr:     `a = fuel == "diesel";`,
+			expected: []int{IDENT, IDENT, EQ, LITERALSTR},
+		},
+		{
+			expr:     `a = 1m!=1m;`,
+			expected: []int{IDENT, NUM, UNIT, NE, NUM, UNIT},
+		},
+		{
+			// a unit only follows a number
+			expr:     `t = d * 2t + s, 5 h;`,
+			expected: []int{IDENT, IDENT, NUM, UNIT, IDENT, NUM, UNIT},
+		},
 	}
 
 	for i, c := range cases {
@@ -0,0 +1,161 @@
+package calcu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// Limits bounds the resources an evaluation might take,
+// the zero value of each limit means unlimited.
+type Limits struct {
+	// MaxStatements limits the statements executed, including
+	// the statements of imported modules, each evaluation of a
+	// script func body is counted as a statement as well.
+	MaxStatements int
+	// MaxDepth limits the AST depth of a statement
+	MaxDepth int
+	// MaxDigits limits the digits of a decimal value
+	MaxDigits int
+	// Timeout limits the wall-clock time of an evaluation
+	Timeout time.Duration
+}
+
+// WithLimits sets the resource limits of the evaluation
+func WithLimits(l Limits) Option {
+	return func(i *Interpreter) {
+		i.limits.Limits = l
+	}
+}
+
+type LimitKind int
+
+const (
+	LimitStatements LimitKind = iota + 1
+	LimitDepth
+	LimitDigits
+	LimitTime
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitStatements:
+		return "statements"
+	case LimitDepth:
+		return "depth"
+	case LimitDigits:
+		return "digits"
+	case LimitTime:
+		return "time"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrLimitExceeded is matched by any *LimitError with errors.Is
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError reports the limit exceeded by the evaluation
+type LimitError struct {
+	Kind LimitKind
+	// Max is the limit, in nanoseconds for LimitTime
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Kind == LimitTime {
+		return fmt.Sprintf("%s limit %s exceeded", e.Kind, time.Duration(e.Max))
+	}
+	return fmt.Sprintf("%s limit %d exceeded", e.Kind, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// limiter tracks the resources of an evaluation, it is shared
+// by the interpreter and the interpreters of the modules.
+type limiter struct {
+	Limits
+
+	ctx        context.Context
+	deadline   time.Time
+	statements int
+}
+
+func newLimiter() *limiter {
+	return &limiter{ctx: context.Background()}
+}
+
+// start begins an evaluation with ctx, the returned
+// func should be called once the evaluation is done.
+func (l *limiter) start(ctx context.Context) func() {
+	cancel := func() {}
+	l.deadline = time.Time{}
+	if l.Timeout > 0 {
+		l.deadline = time.Now().Add(l.Timeout)
+		ctx, cancel = context.WithDeadline(ctx, l.deadline)
+	}
+	l.ctx = ctx
+	l.statements = 0
+	return func() {
+		cancel()
+		l.ctx = context.Background()
+	}
+}
+
+// checkCtx reports the cancellation of the evaluation
+func (l *limiter) checkCtx() error {
+	err := l.ctx.Err()
+	if err == nil {
+		return nil
+	}
+	// the deadline is exceeded by the timeout limit
+	// rather than the deadline of the caller ctx.
+	if errors.Is(err, context.DeadlineExceeded) && !l.deadline.IsZero() && !time.Now().Before(l.deadline) {
+		return &LimitError{Kind: LimitTime, Max: int64(l.Timeout)}
+	}
+	return err
+}
+
+// step counts the statement or the script func body to execute
+func (l *limiter) step(stmt Node) error {
+	if err := l.checkCtx(); err != nil {
+		return err
+	}
+	l.statements++
+	if l.MaxStatements > 0 && l.statements > l.MaxStatements {
+		return &LimitError{Kind: LimitStatements, Max: int64(l.MaxStatements)}
+	}
+	if l.MaxDepth > 0 && depth(stmt) > l.MaxDepth {
+		return &LimitError{Kind: LimitDepth, Max: int64(l.MaxDepth)}
+	}
+	return nil
+}
+
+// checkDigits reports the decimal value grows beyond the digits limit
+func (l *limiter) checkDigits(n Node) error {
+	mv, ok := n.(*MeasureValue)
+	if !ok || mv == nil || l.MaxDigits <= 0 {
+		return nil
+	}
+	if mv.value.NumDigits() > l.MaxDigits {
+		return &LimitError{Kind: LimitDigits, Max: int64(l.MaxDigits)}
+	}
+	return nil
+}
+
+// depth returns the depth of the AST rooted by n
+func depth(n Node) int {
+	max := 0
+	for _, c := range children(n) {
+		if d := depth(c); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
@@ -0,0 +1,125 @@
+package calcu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpretContext(t *testing.T) {
+	wait := func(ctx context.Context, mv *MeasureValue) (*MeasureValue, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return mv, nil
+		}
+	}
+	identity := func(ctx context.Context, mv *MeasureValue) *MeasureValue {
+		if ctx == nil {
+			panic("expect ctx")
+		}
+		return mv
+	}
+	newIntrp := func(opts ...interface{}) *Interpreter {
+		intrp, err := NewInterpreter(nil, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := intrp.RegisterFunc("wait", wait); err != nil {
+			t.Fatal(err)
+		}
+		if err := intrp.RegisterFunc("identity", identity, FuncArgNames("mv")); err != nil {
+			t.Fatal(err)
+		}
+		return intrp
+	}
+
+	t.Run("ctx passed", func(t *testing.T) {
+		intrp := newIntrp()
+		outvars, err := intrp.InterpretContext(context.Background(), bytes.NewBufferString("a = identity(1kg);\nprint(a);"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := outvars["a"].String(); got != "1kg" {
+			t.Fatalf("expected 1kg, got %s", got)
+		}
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		intrp := newIntrp()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		_, err := intrp.InterpretContext(ctx, bytes.NewBufferString("a = wait(1kg);"))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected cancelled, got %v", err)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		intrp := newIntrp(WithLimits(Limits{Timeout: 10 * time.Millisecond}))
+		_, err := intrp.Interpret(bytes.NewBufferString("a = wait(1kg);"))
+		var le *LimitError
+		if !errors.As(err, &le) || le.Kind != LimitTime {
+			t.Fatalf("expected time limit error, got %v", err)
+		}
+		// the interpreter is reusable once the evaluation is done
+		if _, err := intrp.Interpret(bytes.NewBufferString("a = 1kg;")); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLimits(t *testing.T) {
+	cases := []struct {
+		limits Limits
+		expr   string
+		kind   LimitKind
+	}{
+		{limits: Limits{MaxStatements: 2}, expr: "a = 1;\nb = 2;", kind: 0},
+		{limits: Limits{MaxStatements: 2}, expr: "a = 1;\nb = 2;\nc = 3;", kind: LimitStatements},
+		{limits: Limits{MaxDepth: 4}, expr: "a = 1 + 2;", kind: 0},
+		{limits: Limits{MaxDepth: 4}, expr: "a = ((1 + 2));", kind: LimitDepth},
+		{limits: Limits{MaxDepth: 3}, expr: "input a: Mass default ((1kg));", kind: LimitDepth},
+		{limits: Limits{MaxDigits: 10}, expr: "a = 99999 * 99999;", kind: 0},
+		{limits: Limits{MaxDigits: 10}, expr: "a = 999999 * 999999;", kind: LimitDigits},
+		{limits: Limits{MaxDigits: 10}, expr: "func sq(a) = a * a;\na = sq(sq(999));", kind: LimitDigits},
+		{limits: Limits{MaxStatements: 4}, expr: "func f(a) = a;\nb = f(f(1));", kind: 0},
+		{limits: Limits{MaxStatements: 4}, expr: "func f(a) = a;\nb = f(f(f(1)));", kind: LimitStatements},
+		// the calls fan out to 2^40 evaluations
+		{limits: Limits{MaxStatements: 100}, expr: fanOut(40), kind: LimitStatements},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			intrp, err := NewInterpreter(nil, WithLimits(c.limits))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = intrp.Interpret(bytes.NewBufferString(c.expr))
+			if c.kind == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+			var le *LimitError
+			if !errors.As(err, &le) || le.Kind != c.kind || !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("expected %s limit error, got %v", c.kind, err)
+			}
+			t.Log(err)
+		})
+	}
+}
+
+// fanOut returns the script of n funcs each calling the previous twice
+func fanOut(n int) string {
+	var b strings.Builder
+	b.WriteString("func f0(a) = a;\n")
+	for k := 1; k <= n; k++ {
+		fmt.Fprintf(&b, "func f%d(a) = f%d(a) + f%d(a);\n", k, k-1, k-1)
+	}
+	fmt.Fprintf(&b, "x = f%d(1);", n)
+	return b.String()
+}
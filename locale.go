@@ -0,0 +1,189 @@
+package calcu
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// Locale is how the measure values are written outside of the
+// scripts, i.e., the inputs of NewInterpreter and the rendered
+// outputs, the number syntax of the scripts is not affected.
+type Locale struct {
+	// Decimal is the decimal separator, "." if empty
+	Decimal string
+	// Grouping is the digit grouping separator of the thousands,
+	// no grouping if empty. A space grouping accepts the no-break
+	// spaces as well, e.g., 1 234,5 of fr.
+	Grouping string
+	// UnitSpace puts a space between the value and the unit, e.g., 110 kg
+	UnitSpace bool
+	// UnitLabels renders the units by the name of the catalogue,
+	// e.g., 110 Kilogram, the inputs are accepted by both.
+	UnitLabels bool
+}
+
+var (
+	LocaleEN = Locale{Decimal: ".", Grouping: ","}
+	LocaleDE = Locale{Decimal: ",", Grouping: ".", UnitSpace: true}
+	LocaleFR = Locale{Decimal: ",", Grouping: " ", UnitSpace: true}
+	LocaleCH = Locale{Decimal: ".", Grouping: "'", UnitSpace: true}
+)
+
+var locales = map[string]Locale{
+	"en": LocaleEN,
+	"de": LocaleDE,
+	"fr": LocaleFR,
+	"ch": LocaleCH,
+}
+
+// LookupLocale returns the locale of the name, e.g., de
+func LookupLocale(name string) (Locale, bool) {
+	l, ok := locales[strings.ToLower(name)]
+	return l, ok
+}
+
+// WithLocale parses the input vars of NewInterpreter by the locale
+func WithLocale(l Locale) Option {
+	return func(i *Interpreter) {
+		i.locale = &l
+	}
+}
+
+func (l Locale) decimal() string {
+	if l.Decimal == "" {
+		return "."
+	}
+	return l.Decimal
+}
+
+// localeRegexps caches the regexps by the separators of the locales
+var localeRegexps sync.Map
+
+// regexp matches the sign, the integer and the fraction at the
+// start of the input, the grouped integer is tried first. It's
+// compiled once per the separators.
+func (l Locale) regexp() *regexp.Regexp {
+	key := [2]string{l.decimal(), l.Grouping}
+	if re, ok := localeRegexps.Load(key); ok {
+		return re.(*regexp.Regexp)
+	}
+	d := regexp.QuoteMeta(l.decimal())
+	integer := `\d+`
+	if l.Grouping != "" {
+		g := regexp.QuoteMeta(l.Grouping)
+		if l.Grouping == " " {
+			g = `[ \x{00A0}\x{202F}]`
+		}
+		integer = `\d{1,3}(?:` + g + `\d{3})+|\d+`
+	}
+	re, _ := localeRegexps.LoadOrStore(key, regexp.MustCompile(`^([+-]?)(`+integer+`)(?:`+d+`(\d+))?`))
+	return re.(*regexp.Regexp)
+}
+
+// ParseMeasureValue parses s of the locale, e.g., 1.234,5 kg of de,
+// a value without the unit is unitless.
+func (l Locale) ParseMeasureValue(s string) (*MeasureValue, error) {
+	s = strings.TrimSpace(s)
+	m := l.regexp().FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid measure value: %s", s)
+	}
+	integer := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s[m[4]:m[5]])
+	num := s[m[2]:m[3]] + integer
+	if m[6] >= 0 {
+		num += "." + s[m[6]:m[7]]
+	}
+	d, err := decimal.NewFromString(num)
+	if err != nil {
+		return nil, fmt.Errorf("invalid measure value: %s", s)
+	}
+	unit := strings.TrimSpace(s[m[1]:])
+	if unit == "" {
+		return &MeasureValue{um: StdUm, value: d, unitless: true}, nil
+	}
+	u, ok := StdUm.GetByName(unit)
+	if !ok {
+		u, ok = l.unitByLabel(unit)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid measure value: %s", s)
+	}
+	return &MeasureValue{um: StdUm, value: d, unit: u.Name()}, nil
+}
+
+// unitByLabel looks up the unit by the name of the catalogue
+func (l Locale) unitByLabel(label string) (Unit, bool) {
+	if !l.UnitLabels {
+		return nil, false
+	}
+	units, _ := StdUm.ListMetaUnitsByDims(Dimensions()...)
+	for _, u := range units {
+		if strings.EqualFold(u.Label(), label) {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// Format renders mv of the locale, e.g., 1.234,5 kg of de
+func (l Locale) Format(mv *MeasureValue) string {
+	var b strings.Builder
+	b.WriteString(l.FormatDecimal(mv.value))
+	if mv.unitless || mv.unit == "" {
+		return b.String()
+	}
+	if l.UnitSpace {
+		b.WriteString(" ")
+	}
+	b.WriteString(l.unitLabel(mv))
+	return b.String()
+}
+
+func (l Locale) unitLabel(mv *MeasureValue) string {
+	if l.UnitLabels {
+		switch u, _ := mv.um.GetByName(mv.unit); u := u.(type) {
+		case *MetaUnit:
+			return u.label
+		case *CompoundUnit:
+			return u.Numerator.label + "/" + u.Denominator.label
+		}
+	}
+	if l.UnitSpace {
+		// the space separates the unit, e.g., 110 10^3m3
+		return mv.unit
+	}
+	s, _ := MaybeAmbiguousUnitName(mv.unit)
+	return s
+}
+
+// FormatDecimal renders d of the locale, e.g., 1.234,5 of de
+func (l Locale) FormatDecimal(d decimal.Decimal) string {
+	s := d.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	b.WriteString(sign)
+	for k := range integer {
+		if k > 0 && (len(integer)-k)%3 == 0 {
+			b.WriteString(l.Grouping)
+		}
+		b.WriteByte(integer[k])
+	}
+	if frac != "" {
+		b.WriteString(l.decimal())
+		b.WriteString(frac)
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package calcu
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestLocaleParse(t *testing.T) {
+	labels := LocaleDE
+	labels.UnitLabels = true
+	cases := []struct {
+		locale   Locale
+		s        string
+		expected string
+	}{
+		{locale: LocaleDE, s: "1.234,5 kg", expected: "1234.5kg"},
+		{locale: LocaleDE, s: "1234,5kg", expected: "1234.5kg"},
+		{locale: LocaleDE, s: "-0,25 t/km", expected: "-0.25t/km"},
+		{locale: LocaleDE, s: "1.234.567", expected: "1234567"},
+		{locale: LocaleDE, s: "2 tonnes", expected: "2t"},
+		{locale: LocaleDE, s: "1 (10^3m3)", expected: "1(10^3m3)"},
+		{locale: LocaleDE, s: "1 kg/(10^3m3)", expected: "1kg/10^3m3"},
+		{locale: LocaleDE, s: "1 (kg/t)", expected: "1kg/t"},
+		{locale: LocaleFR, s: "1\u00a0234,5 t", expected: "1234.5t"},
+		{locale: LocaleFR, s: "1\u202f234,5 t", expected: "1234.5t"},
+		{locale: LocaleFR, s: "1 234 kg", expected: "1234kg"},
+		{locale: LocaleCH, s: "1'234.5 kWh", expected: "1234.5kWh"},
+		{locale: LocaleEN, s: "1,234.5kg", expected: "1234.5kg"},
+		{locale: Locale{}, s: "1234.5 kg", expected: "1234.5kg"},
+		{locale: labels, s: "1,5 Kilogram", expected: "1.5kg"},
+		{locale: LocaleDE, s: "1,5 Kilogram"},
+		{locale: LocaleDE, s: "1.5 kg"},
+		{locale: LocaleDE, s: "1,5 xx"},
+		{locale: LocaleDE, s: "kg"},
+		{locale: LocaleFR, s: "1 23 kg"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mv, err := c.locale.ParseMeasureValue(c.s)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", mv)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mv.String(); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+
+	// the regexp is compiled once per the separators
+	if LocaleDE.regexp() != LocaleDE.regexp() || LocaleDE.regexp() == LocaleFR.regexp() {
+		t.Fatalf("expected the regexp cached per locale")
+	}
+}
+
+func TestLocaleFormat(t *testing.T) {
+	labels := LocaleEN
+	labels.UnitSpace, labels.UnitLabels = true, true
+	cases := []struct {
+		locale   Locale
+		s        string
+		expected string
+	}{
+		{locale: LocaleDE, s: "1234567.891kg", expected: "1.234.567,891 kg"},
+		{locale: LocaleDE, s: "-1234.5t", expected: "-1.234,5 t"},
+		{locale: LocaleDE, s: "123", expected: "123"},
+		{locale: LocaleFR, s: "1234.5t", expected: "1 234,5 t"},
+		{locale: LocaleCH, s: "1234.5kWh", expected: "1'234.5 kWh"},
+		{locale: LocaleEN, s: "1234.5kg", expected: "1,234.5kg"},
+		{locale: LocaleEN, s: "1(10^3m3)", expected: "1(10^3m3)"},
+		{locale: Locale{}, s: "1234.5kg", expected: "1234.5kg"},
+		{locale: labels, s: "1500kg", expected: "1,500 Kilogram"},
+		{locale: labels, s: "2t/km", expected: "2 Tonne/Kilometer"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mv, err := makeMeasureValueFromString(c.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.locale.Format(mv); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithLocale(t *testing.T) {
+	vars := map[string]string{"activity": "1.234,5 t", "fuel": "diesel"}
+	intrp, err := NewInterpreter(vars, WithLocale(LocaleDE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the numbers of the script are not affected
+	outvars, err := intrp.Interpret(bytes.NewBufferString("a = activity * 2.5;\nprint(a);"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := LocaleDE.Format(outvars["a"]); got != "3.086,25 t" {
+		t.Fatalf("expected 3.086,25 t, got %s", got)
+	}
+	if got := intrp.StrVars()["fuel"]; got != "diesel" {
+		t.Fatalf("expected diesel, got %s", got)
+	}
+
+	// without the locale, the inputs are of the script syntax
+	if _, err := NewInterpreter(vars); err == nil {
+		t.Fatalf("expected err")
+	}
+}
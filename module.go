@@ -0,0 +1,134 @@
+package calcu
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WithFS sets the file system the imported
+// modules are resolved from.
+func WithFS(fsys fs.FS) Option {
+	return func(i *Interpreter) {
+		i.modules.fsys = fsys
+	}
+}
+
+// moduleLoader resolves imported modules, it is shared
+// by the interpreter and the interpreters of the modules
+// it imports, so that each module is parsed only once.
+type moduleLoader struct {
+	fsys fs.FS
+
+	// parsed modules by path
+	parsed map[string]*Script
+	// modules being imported, for cycle detection
+	loading map[string]bool
+}
+
+func newModuleLoader() *moduleLoader {
+	return &moduleLoader{
+		parsed:  make(map[string]*Script),
+		loading: make(map[string]bool),
+	}
+}
+
+var namespaceRe = regexp.MustCompile("^[_a-zA-Z][_a-zA-Z0-9]*$")
+
+// namespace returns the module name by its path,
+// e.g., the namespace of lib/fuels.calc is fuels
+func namespace(p string) (string, error) {
+	base := path.Base(p)
+	ns := strings.TrimSuffix(base, path.Ext(base))
+	if !namespaceRe.MatchString(ns) {
+		return "", fmt.Errorf("invalid module name %s", ns)
+	}
+	return ns, nil
+}
+
+// load returns the parsed module
+func (ml *moduleLoader) load(p string) (*Script, error) {
+	if script, ok := ml.parsed[p]; ok {
+		return script, nil
+	}
+	if ml.fsys == nil {
+		return nil, errors.New("no file system to import from")
+	}
+	f, err := ml.fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	script, err := Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	ml.parsed[p] = script
+	return script, nil
+}
+
+// visitImport evaluates the module with a fresh interpreter,
+// and binds the vars and script funcs of the module to the
+// current one namespaced by the module name. The script funcs
+// are still evaluated by the module interpreter, i.e., they
+// see the vars of the module, not the importer.
+func (i *Interpreter) visitImport(a *Import) error {
+	p := path.Clean(a.path)
+	if !fs.ValidPath(p) {
+		return fmt.Errorf("import %s: invalid path", a.path)
+	}
+	ns, err := namespace(p)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", a.path, err)
+	}
+	ml := i.modules
+	if ml.loading[p] {
+		return fmt.Errorf("import %s: import cycle not allowed", a.path)
+	}
+	ml.loading[p] = true
+	defer delete(ml.loading, p)
+
+	script, err := ml.load(p)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", a.path, err)
+	}
+
+	mi := newInterpreter(make(MeasureVars), make(map[string]*LiteralString))
+	mi.modules = ml
+	mi.limits = i.limits
+	mi.fuels = i.fuels
+	mi.rates = i.rates
+	mi.prices = i.prices
+	mi.maxCallDepth = i.maxCallDepth
+	// the go funcs are visible to the module
+	for name, f := range i.funcs {
+		if f.def == nil {
+			mi.funcs[name] = f
+		}
+	}
+	// the inputs of the module are of the defaults only
+	if err := mi.checkInputs(script.Stmts); err != nil {
+		return fmt.Errorf("import %s: %w", a.path, err)
+	}
+	for _, s := range script.Stmts {
+		if err := mi.visitRoot(s.Node); err != nil {
+			return fmt.Errorf("import %s: %w", a.path, err)
+		}
+	}
+
+	for name, mv := range mi.mvvars {
+		i.setVar(ns+"."+name, mv)
+	}
+	for name, str := range mi.strvars {
+		i.setVar(ns+"."+name, str)
+	}
+	for name, f := range mi.funcs {
+		if f.def != nil {
+			i.funcs[ns+"."+name] = f
+		}
+	}
+	return nil
+}
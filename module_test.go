@@ -0,0 +1,113 @@
+package calcu
+
+import (
+	"bytes"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/fstest"
+)
+
+type countingFS struct {
+	fs.FS
+	opens map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.opens[name]++
+	return c.FS.Open(name)
+}
+
+func TestInterpreterImport(t *testing.T) {
+	fsys := &countingFS{
+		FS: fstest.MapFS{
+			"lib/fuels.calc": {Data: []byte(`
+import "lib/consts.calc";
+diesel_ncv = 43Tj/Gg;
+kind = "diesel";
+func energy(mass) = mass * diesel_ncv * consts.ox;
+`)},
+			"lib/consts.calc": {Data: []byte(`
+ox = 0.5;
+`)},
+		},
+		opens: make(map[string]int),
+	}
+	exprs := `
+import "lib/fuels.calc";
+import "lib/consts.calc";
+ncv = fuels.diesel_ncv;
+e = fuels.energy(2Gg);
+ox = fuels.consts.ox + consts.ox;
+diesel_ncv = 1Tj/Gg;
+e2 = fuels.energy(2Gg);
+kind = fuels.kind;
+print(ncv, e, ox, e2, kind);
+`
+	intrp, err := NewInterpreter(nil, WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(exprs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["ncv"].String(), outvars["e"].String(), outvars["ox"].String(),
+		outvars["e2"].String(), intrp.OutStrings()["kind"]}
+	expected := []string{"43Tj/Gg", "43000000000000N.m", "1", "43000000000000N.m", "diesel"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("exptectd: %v, got: %v", expected, gots)
+	}
+	if n := fsys.opens["lib/consts.calc"]; n != 1 {
+		t.Fatalf("expected module parsed once, got %d", n)
+	}
+}
+
+func TestInterpreterImportErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.calc":   {Data: []byte(`import "b.calc";`)},
+		"b.calc":   {Data: []byte(`import "a.calc";`)},
+		"bad.calc": {Data: []byte(`x = 1kg + 1m3;`)},
+		"1x.calc":  {Data: []byte(`x = 1;`)},
+		"ok.calc":  {Data: []byte(`x = 1;`)},
+		"in.calc":  {Data: []byte(`input x: Mass;`)},
+		"def.calc": {Data: []byte("input x: Mass default 2kg;\ny = x * 2;")},
+	}
+	cases := []struct {
+		expr string
+		ok   bool
+		hint string
+	}{
+		{expr: `import "ok.calc";`, ok: true},
+		{expr: `import "a.calc";`, ok: false, hint: "import cycle"},
+		{expr: `import "bad.calc";`, ok: false, hint: "evaluation error in module"},
+		{expr: `import "missing.calc";`, ok: false, hint: "module not found"},
+		{expr: `import "1x.calc";`, ok: false, hint: "invalid module name"},
+		{expr: `import "../ok.calc";`, ok: false, hint: "invalid path"},
+		{expr: "import \"ok.calc\";\nok.x = 2;", ok: false, hint: "assign to imported var"},
+		{expr: "func ok.f(a) = a;", ok: false, hint: "define imported func"},
+		{expr: `import "in.calc";`, ok: false, hint: "missing input of module"},
+		{expr: "import \"def.calc\";\nz = def.y;", ok: true},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(nil, WithFS(fsys))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = intrp.Interpret(bytes.NewBufferString(c.expr))
+			switch c.ok {
+			case false:
+				if err == nil {
+					t.Fatalf("expected err, got nil")
+				}
+				t.Log(err)
+			case true:
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+		})
+	}
+}
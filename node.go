@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -22,6 +23,10 @@ const (
 	NodeTypeList
 	NodeTypeAssignment
 	NodeTypeParenExpr
+	NodeTypeFuncDef
+	NodeTypeImport
+	NodeTypeInput
+	NodeTypeOutput
 )
 
 func (t NodeType) String() string {
@@ -30,6 +35,8 @@ func (t NodeType) String() string {
 
 type Node interface {
 	Type() NodeType
+	// String prints the node back to source
+	String() string
 }
 
 type MeasureValue struct {
@@ -52,11 +59,20 @@ func makeUnitlessMeasureValue(value string) (*MeasureValue, error) {
 	}, nil
 }
 
+// makeUnitlessBool represents a boolean as unitless 1 or 0
+func makeUnitlessBool(b bool) *MeasureValue {
+	d := decimal.Zero
+	if b {
+		d = decimal.NewFromInt(1)
+	}
+	return &MeasureValue{um: StdUm, value: d, unitless: true}
+}
+
 func MakeMeasureValueFromDecimal(d decimal.Decimal, unit string) *MeasureValue {
 	return &MeasureValue{
 		um:    StdUm,
 		value: d,
-		unit:  unit,
+		unit:  canonicalUnit(StdUm, unit),
 	}
 }
 
@@ -65,7 +81,7 @@ func makeMeasureValue(value, unit string) (*MeasureValue, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &MeasureValue{um: StdUm, value: d, unit: unit}, nil
+	return &MeasureValue{um: StdUm, value: d, unit: canonicalUnit(StdUm, unit)}, nil
 }
 
 func makeMeasureValueFromString(s string) (*MeasureValue, error) {
@@ -103,28 +119,26 @@ func (mv *MeasureValue) To(targetUnitName string) (*MeasureValue, error) {
 	if !ok {
 		return nil, fmt.Errorf("unit %s not found", mv.unit)
 	}
+	if !isSameDimension(mvunit, tunit) {
+		return nil, fmt.Errorf("convert %s to %s is unsupported%s", mv.unit, targetUnitName, gasHint(mv.unit, tunit.Name()))
+	}
 	// target unit is si unit
 	si := mv.toSi(mvunit)
 	if si.unit == tunit.Name() {
 		return si, nil
 	}
 	// target unit is not si unit
-	tFactor, tOffset := tunit.SiFactors()
-	d := si.value.Div(tFactor)
-	d = d.Sub(tOffset)
 	return &MeasureValue{
 		um:       mv.um,
 		unit:     tunit.Name(),
 		unitless: false,
-		value:    d,
+		value:    fromSiValue(tunit, si.value),
 	}, nil
 }
 
 func (mv *MeasureValue) toSi(mvUnit Unit) *MeasureValue {
 	siName := mvUnit.SiName()
-	siFactor, siOffset := mvUnit.SiFactors()
-	d := mv.value.Mul(siFactor)
-	d = d.Add(siOffset)
+	d := siValue(mvUnit, mv.value)
 	unitless := siName == ""
 	return &MeasureValue{
 		um:       mv.um,
@@ -162,7 +176,7 @@ func (mv *MeasureValue) parseAdd(other *MeasureValue) (*mvopstat, bool) {
 	if !ok {
 		return nil, false
 	}
-	if u.Dimension() != ou.Dimension() {
+	if !isSameDimension(u, ou) {
 		return nil, false
 	}
 	lmv, rmv := mv.toSi(u), other.toSi(ou)
@@ -217,7 +231,7 @@ func (mv *MeasureValue) parseMul(other *MeasureValue) (*mvopstat, bool) {
 	// if both are meta unit, compatible if dimension is same,
 	// otherwise not allowed.
 	if u.IsMeta() && ou.IsMeta() {
-		if u.Dimension() != ou.Dimension() {
+		if !isConvertible(u.(*MetaUnit), ou.(*MetaUnit)) {
 			return nil, false
 		}
 		lmv, rmv := mv.toSi(u), other.toSi(ou)
@@ -243,10 +257,7 @@ func (mv *MeasureValue) parseMul(other *MeasureValue) (*mvopstat, bool) {
 				rmv:      rmv,
 			}, true
 		}
-		if cu1.Numerator.Dimension() != cu2.Numerator.Dimension() {
-			return nil, false
-		}
-		if cu1.Denominator.Dimension() != cu2.Denominator.Dimension() {
+		if !cu1.IsDivCancelable(cu2) {
 			return nil, false
 		}
 		lmv, rmv := mv.toSi(u), other.toSi(ou)
@@ -277,7 +288,7 @@ func (mv *MeasureValue) parseMul(other *MeasureValue) (*mvopstat, bool) {
 	}
 	// if the dimension of denominator of compound is same as the dimension
 	// of meta unit, otherwise not allowed.
-	if cu.Denominator.Dimension() != mu.Dimension() {
+	if !isConvertible(cu.Denominator, mu) {
 		return nil, false
 	}
 
@@ -329,7 +340,7 @@ func (mv *MeasureValue) parseDiv(other *MeasureValue) (*mvopstat, bool) {
 	// if both are meta unit, compatible if dimension is same,
 	// otherwise not allowed.
 	if u.IsMeta() && ou.IsMeta() {
-		if u.Dimension() != ou.Dimension() {
+		if !isConvertible(u.(*MetaUnit), ou.(*MetaUnit)) {
 			return nil, false
 		}
 		lmv, rmv := mv.toSi(u), other.toSi(ou)
@@ -361,19 +372,92 @@ func (mv *MeasureValue) parseDiv(other *MeasureValue) (*mvopstat, bool) {
 	return nil, false
 }
 
+// metaUnit returns the meta unit of mv, or nil if
+// mv is unitless or of a compound unit.
+func (mv *MeasureValue) metaUnit() *MetaUnit {
+	if mv.unitless {
+		return nil
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return nil
+	}
+	mu, _ := u.(*MetaUnit)
+	return mu
+}
+
+func (mv *MeasureValue) isAbsolute() bool {
+	mu := mv.metaUnit()
+	return mu != nil && mu.isAbsolute()
+}
+
+// addAbsolute adds or subtracts the values if either of them is an
+// absolute temperature, e.g., °C. An absolute temperature plus or
+// minus a delta, e.g., Δ°C or K, is absolute, the difference of
+// two absolute temperatures is a delta in the unit of mv, so is K
+// minus an absolute temperature, adding two absolute temperatures
+// is unsupported. It reports false if neither of them is absolute.
+func (mv *MeasureValue) addAbsolute(other *MeasureValue, op string) (*MeasureValue, bool, error) {
+	u, ou := mv.metaUnit(), other.metaUnit()
+	if !mv.isAbsolute() && !other.isAbsolute() {
+		return nil, false, nil
+	}
+	unsupported := fmt.Errorf("(%s)%s(%s) is unsupported", mv.unit, op, other.unit)
+	if u == nil || ou == nil || !isConvertible(u, ou) {
+		return nil, true, unsupported
+	}
+	switch {
+	case u.isAbsolute() && ou.isAbsolute():
+		if op != OpSub {
+			return nil, true, unsupported
+		}
+		deltaUnit, ok := mv.um.GetByName(deltaPrefix + u.name)
+		if !ok {
+			return nil, true, unsupported
+		}
+		d := siValue(u, mv.value).Sub(siValue(ou, other.value))
+		return &MeasureValue{um: mv.um, unit: deltaUnit.Name(), value: fromSiValue(deltaUnit, d)}, true, nil
+	case u.isAbsolute():
+		// the delta of other in the scale of u
+		d := u.unscale(ou.scale(other.value))
+		if op == OpSub {
+			d = d.Neg()
+		}
+		return mv.withValue(mv.value.Add(d)), true, nil
+	default:
+		// K serves as both, K minus an absolute temperature is
+		// the difference in K, e.g., 300K - 20°C is 6.85K.
+		if op == OpSub && !u.isDelta() && u.dimension == DimTemperature {
+			d := siValue(u, mv.value).Sub(siValue(ou, other.value))
+			return mv.withValue(fromSiValue(u, d)), true, nil
+		}
+		// a delta minus an absolute temperature is meaningless
+		if op == OpSub {
+			return nil, true, unsupported
+		}
+		return other.withValue(other.value.Add(ou.unscale(u.scale(mv.value)))), true, nil
+	}
+}
+
 func (mv *MeasureValue) Add(other *MeasureValue) (*MeasureValue, error) {
+	if ans, ok, err := mv.addAbsolute(other, OpAdd); ok {
+		return ans, err
+	}
 	mvos, ok := mv.parseAdd(other)
 	if !ok {
-		return nil, fmt.Errorf("(%s)+(%s) is unsupported", mv.unit, other.unit)
+		return nil, fmt.Errorf("(%s)+(%s) is unsupported%s", mv.unit, other.unit, gasHint(mv.unit, other.unit))
 	}
 	d := mvos.lmv.value.Add(mvos.rmv.value)
 	return &MeasureValue{um: mv.um, value: d, unitless: mvos.unitless, unit: mvos.targetUnit}, nil
 }
 
 func (mv *MeasureValue) Sub(other *MeasureValue) (*MeasureValue, error) {
+	if ans, ok, err := mv.addAbsolute(other, OpSub); ok {
+		return ans, err
+	}
 	mvos, ok := mv.parseAdd(other)
 	if !ok {
-		return nil, fmt.Errorf("(%s)-(%s) is unsupported", mv.unit, other.unit)
+		return nil, fmt.Errorf("(%s)-(%s) is unsupported%s", mv.unit, other.unit, gasHint(mv.unit, other.unit))
 	}
 	d := mvos.lmv.value.Sub(mvos.rmv.value)
 	return &MeasureValue{um: mv.um, value: d, unitless: mvos.unitless, unit: mvos.targetUnit}, nil
@@ -381,7 +465,9 @@ func (mv *MeasureValue) Sub(other *MeasureValue) (*MeasureValue, error) {
 
 func (mv *MeasureValue) Mul(other *MeasureValue) (*MeasureValue, error) {
 	mvos, ok := mv.parseMul(other)
-	if !ok {
+	// scaling an absolute temperature is meaningless,
+	// e.g., 2 * 20°C, use the delta instead, e.g., 2 * 20Δ°C
+	if !ok || mv.isAbsolute() || other.isAbsolute() {
 		return nil, fmt.Errorf("(%s)*(%s) is unsupported", mv.unit, other.unit)
 	}
 	d := mvos.lmv.value.Mul(mvos.rmv.value)
@@ -390,15 +476,116 @@ func (mv *MeasureValue) Mul(other *MeasureValue) (*MeasureValue, error) {
 
 func (mv *MeasureValue) Div(other *MeasureValue) (*MeasureValue, error) {
 	mvos, ok := mv.parseDiv(other)
-	if !ok {
+	if !ok || mv.isAbsolute() || other.isAbsolute() {
 		return nil, fmt.Errorf("(%s)/(%s) is unsupported", mv.unit, other.unit)
 	}
+	if mvos.rmv.value.IsZero() {
+		return nil, fmt.Errorf("(%s)/(%s): division by zero", mv, other)
+	}
 	d := mvos.lmv.value.Div(mvos.rmv.value)
 	return &MeasureValue{um: mv.um, value: d, unitless: mvos.unitless, unit: mvos.targetUnit}, nil
 }
 
 func (mv *MeasureValue) Neg() *MeasureValue {
-	return &MeasureValue{value: mv.value.Neg()}
+	return mv.withValue(mv.value.Neg())
+}
+
+// Percent returns mv/100 in the unit of mv, e.g., 5% is 0.05
+func (mv *MeasureValue) Percent() *MeasureValue {
+	return mv.withValue(mv.value.Div(decimal.NewFromInt(100)))
+}
+
+// Cmp compares the values through their si, it returns -1 if mv < other,
+// 0 if mv == other and +1 if mv > other. The values should be both
+// unitless or of convertible units, e.g., 110kg and 0.11t.
+func (mv *MeasureValue) Cmp(other *MeasureValue) (int, error) {
+	if mv.unitless && other.unitless {
+		return mv.value.Cmp(other.value), nil
+	}
+	if mv.unitless || other.unitless {
+		return 0, fmt.Errorf("compare (%s) with (%s) is unsupported", mv.unit, other.unit)
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return 0, fmt.Errorf("unit %s not found", mv.unit)
+	}
+	ou, ok := other.um.GetByName(other.unit)
+	if !ok {
+		return 0, fmt.Errorf("unit %s not found", other.unit)
+	}
+	if !isSameDimension(u, ou) {
+		return 0, fmt.Errorf("compare (%s) with (%s) is unsupported%s", mv.unit, other.unit, gasHint(mv.unit, other.unit))
+	}
+	return mv.toSi(u).value.Cmp(other.toSi(ou).value), nil
+}
+
+// Equal reports whether the values are equal through their si,
+// values that can not be compared are not equal.
+func (mv *MeasureValue) Equal(other *MeasureValue) bool {
+	c, err := mv.Cmp(other)
+	return err == nil && c == 0
+}
+
+func (mv *MeasureValue) IsZero() bool {
+	return mv.value.IsZero()
+}
+
+// Sign returns -1 if mv < 0, 0 if mv == 0 and +1 if mv > 0
+func (mv *MeasureValue) Sign() int {
+	return mv.value.Sign()
+}
+
+func (mv *MeasureValue) Abs() *MeasureValue {
+	return mv.withValue(mv.value.Abs())
+}
+
+// Round rounds the value to places decimal places in the unit of mv,
+// e.g., 1.255kg rounds to 1.26kg with places 2.
+func (mv *MeasureValue) Round(places int32) *MeasureValue {
+	return mv.withValue(mv.value.Round(places))
+}
+
+// Truncate truncates the value to places decimal places in the unit of mv
+func (mv *MeasureValue) Truncate(places int32) *MeasureValue {
+	return mv.withValue(mv.value.Truncate(places))
+}
+
+// Dimension returns the dimension of the unit, it is DimInvalid for
+// the unitless values and the compound units.
+func (mv *MeasureValue) Dimension() Dimension {
+	if mv.unitless {
+		return DimInvalid
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return DimInvalid
+	}
+	return u.Dimension()
+}
+
+func (mv *MeasureValue) IsUnitless() bool {
+	return mv.unitless
+}
+
+// ConvertibleTo check if mv can be converted to the unit by To
+func (mv *MeasureValue) ConvertibleTo(unit string) bool {
+	if mv.unitless {
+		return false
+	}
+	u, ok := mv.um.GetByName(mv.unit)
+	if !ok {
+		return false
+	}
+	tu, ok := mv.um.GetByName(unit)
+	if !ok {
+		return false
+	}
+	return isSameDimension(u, tu)
+}
+
+// withValue returns a copy of mv with the value replaced
+func (mv *MeasureValue) withValue(d decimal.Decimal) *MeasureValue {
+	return &MeasureValue{um: mv.um, unit: mv.unit, unitless: mv.unitless, value: d}
 }
 
 func (mv *MeasureValue) String() string {
@@ -433,6 +620,15 @@ func (n *LiteralString) Type() NodeType {
 	return NodeTypeLiteralStr
 }
 
+func (n *LiteralString) String() string {
+	return `"` + n.s + `"`
+}
+
+// Value returns the string without quotes
+func (n *LiteralString) Value() string {
+	return n.s
+}
+
 type Variable struct {
 	Name string
 }
@@ -445,6 +641,10 @@ func (v *Variable) Type() NodeType {
 	return NodeTypeVar
 }
 
+func (v *Variable) String() string {
+	return v.Name
+}
+
 type BinaryExpr struct {
 	Op  string
 	lhs Node
@@ -456,6 +656,8 @@ const (
 	OpSub = "-"
 	OpMul = "*"
 	OpDiv = "/"
+	OpEq  = "=="
+	OpNe  = "!="
 )
 
 func makeBinaryExpr(lhs, rhs Node, op string) *BinaryExpr {
@@ -470,18 +672,45 @@ func (n *BinaryExpr) Type() NodeType {
 	return NodeTypeBinaryExpr
 }
 
+func (n *BinaryExpr) LHS() Node {
+	return n.lhs
+}
+
+func (n *BinaryExpr) RHS() Node {
+	return n.rhs
+}
+
+func (n *BinaryExpr) String() string {
+	return n.lhs.String() + " " + n.Op + " " + n.rhs.String()
+}
+
+// OpPct is the percentage postfix, e.g., 5% is 0.05
+const OpPct = "%"
+
 type UnaryExpr struct {
+	Op   string
 	expr Node
 }
 
-func makeUnaryExpr(expr Node) *UnaryExpr {
-	return &UnaryExpr{expr: expr}
+func makeUnaryExpr(expr Node, op string) *UnaryExpr {
+	return &UnaryExpr{Op: op, expr: expr}
 }
 
 func (n *UnaryExpr) Type() NodeType {
 	return NodeTypeUnaryExpr
 }
 
+func (n *UnaryExpr) Expr() Node {
+	return n.expr
+}
+
+func (n *UnaryExpr) String() string {
+	if n.Op == OpPct {
+		return n.expr.String() + n.Op
+	}
+	return n.Op + n.expr.String()
+}
+
 type ParenExpr struct {
 	expr Node
 }
@@ -494,19 +723,47 @@ func (n *ParenExpr) Type() NodeType {
 	return NodeTypeParenExpr
 }
 
+func (n *ParenExpr) Expr() Node {
+	return n.expr
+}
+
+func (n *ParenExpr) String() string {
+	return "(" + n.expr.String() + ")"
+}
+
 type FuncCall struct {
 	fn   string
 	args []Node
+	// pos is the column of the func name
+	pos int
 }
 
-func makeFuncCall(fn string, args ...Node) (*FuncCall, error) {
-	return &FuncCall{fn: fn, args: args}, nil
+func makeFuncCall(fn string, pos int, args ...Node) (*FuncCall, error) {
+	return &FuncCall{fn: fn, args: args, pos: pos}, nil
 }
 
 func (fc *FuncCall) Type() NodeType {
 	return NodeTypeFuncCall
 }
 
+// Name returns the func name, might be namespaced, e.g., fuels.ncv
+func (fc *FuncCall) Name() string {
+	return fc.fn
+}
+
+func (fc *FuncCall) Args() []Node {
+	return fc.args
+}
+
+// Pos returns the 1-based column of the func name
+func (fc *FuncCall) Pos() int {
+	return fc.pos
+}
+
+func (fc *FuncCall) String() string {
+	return fc.fn + "(" + joinNodes(fc.args) + ")"
+}
+
 type List struct {
 	elements []Node
 }
@@ -523,12 +780,23 @@ func (l *List) Type() NodeType {
 	return NodeTypeList
 }
 
+func (l *List) Elements() []Node {
+	return l.elements
+}
+
+func (l *List) String() string {
+	return joinNodes(l.elements)
+}
+
 type Assignment struct {
 	variable string
 	node     Node
 }
 
 func makeAssignment(variable string, node Node) (*Assignment, error) {
+	if isNamespaced(variable) {
+		return nil, fmt.Errorf("assign to imported var %s not allowed", variable)
+	}
 	return &Assignment{
 		variable: variable,
 		node:     node,
@@ -538,3 +806,221 @@ func makeAssignment(variable string, node Node) (*Assignment, error) {
 func (n *Assignment) Type() NodeType {
 	return NodeTypeAssignment
 }
+
+// Name returns the name of the var assigned
+func (n *Assignment) Name() string {
+	return n.variable
+}
+
+func (n *Assignment) Expr() Node {
+	return n.node
+}
+
+func (n *Assignment) String() string {
+	return n.variable + " = " + n.node.String()
+}
+
+// FuncDef is a script defined func, e.g.,
+// func emission(a, f) = a * f;
+type FuncDef struct {
+	name   string
+	params []string
+	body   Node
+}
+
+func makeFuncDef(name string, params []string, body Node) (*FuncDef, error) {
+	if isNamespaced(name) {
+		return nil, fmt.Errorf("define imported func %s not allowed", name)
+	}
+	seen := make(map[string]bool, len(params))
+	for _, p := range params {
+		if isNamespaced(p) {
+			return nil, fmt.Errorf("invalid param %s of func %s", p, name)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("duplicate param %s of func %s", p, name)
+		}
+		seen[p] = true
+	}
+	return &FuncDef{name: name, params: params, body: body}, nil
+}
+
+func (n *FuncDef) Type() NodeType {
+	return NodeTypeFuncDef
+}
+
+func (n *FuncDef) Name() string {
+	return n.name
+}
+
+func (n *FuncDef) Params() []string {
+	return n.params
+}
+
+func (n *FuncDef) Body() Node {
+	return n.body
+}
+
+func (n *FuncDef) String() string {
+	return "func " + n.name + "(" + strings.Join(n.params, ", ") + ") = " + n.body.String()
+}
+
+// Import imports a module, e.g., import "lib/fuels.calc";
+// the vars and funcs of the module are namespaced by the
+// module name, i.e., fuels.diesel_ncv
+type Import struct {
+	path string
+}
+
+func makeImport(path string) (*Import, error) {
+	return &Import{path: path}, nil
+}
+
+func (n *Import) Type() NodeType {
+	return NodeTypeImport
+}
+
+func (n *Import) Path() string {
+	return n.path
+}
+
+func (n *Import) String() string {
+	return `import "` + n.path + `"`
+}
+
+// Input declares an input var of the script, e.g.,
+// input activity_value: Volume default 0m3 range [0, 1e9];
+type Input struct {
+	name string
+	dim  Dimension
+	// unitless is of the Unitless inputs, e.g., ratios, the dim is invalid
+	unitless bool
+	// def is the default value, nil if required
+	def Node
+	// lo and hi are the inclusive bounds, nil if unbounded
+	lo, hi Node
+}
+
+func makeInput(name, dim string, def Node, bounds *List) (*Input, error) {
+	if isNamespaced(name) {
+		return nil, fmt.Errorf("invalid input %s", name)
+	}
+	d := DimensionFromString(dim)
+	unitless := dim == "Unitless"
+	if d == DimInvalid && !unitless {
+		return nil, fmt.Errorf("unknown dimension %s of input %s", dim, name)
+	}
+	n := &Input{name: name, dim: d, unitless: unitless, def: def}
+	if bounds != nil {
+		n.lo, n.hi = bounds.elements[0], bounds.elements[1]
+	}
+	return n, nil
+}
+
+func (n *Input) Type() NodeType {
+	return NodeTypeInput
+}
+
+func (n *Input) Name() string {
+	return n.name
+}
+
+// Dimension returns the dimension, DimInvalid if unitless
+func (n *Input) Dimension() Dimension {
+	return n.dim
+}
+
+// IsUnitless returns true if the input is declared Unitless
+func (n *Input) IsUnitless() bool {
+	return n.unitless
+}
+
+func (n *Input) dimName() string {
+	if n.unitless {
+		return "Unitless"
+	}
+	return n.dim.String()
+}
+
+// Default returns the default value, nil if required
+func (n *Input) Default() Node {
+	return n.def
+}
+
+// Range returns the inclusive bounds, nil if unbounded
+func (n *Input) Range() (lo, hi Node) {
+	return n.lo, n.hi
+}
+
+func (n *Input) String() string {
+	ans := "input " + n.name + ": " + n.dimName()
+	if n.def != nil {
+		ans += " default " + n.def.String()
+	}
+	if n.lo != nil {
+		ans += " range [" + n.lo.String() + ", " + n.hi.String() + "]"
+	}
+	return ans
+}
+
+// Output declares an output var of the script, e.g.,
+// output GHG in t as "Total GHG emissions";
+type Output struct {
+	name string
+	// unit is the display unit, empty if as is
+	unit  string
+	label string
+	desc  string
+}
+
+func makeOutput(name, unit, label, desc string) (*Output, error) {
+	return &Output{name: name, unit: unit, label: label, desc: desc}, nil
+}
+
+func (n *Output) Type() NodeType {
+	return NodeTypeOutput
+}
+
+func (n *Output) Name() string {
+	return n.name
+}
+
+// Unit returns the display unit, empty if none
+func (n *Output) Unit() string {
+	return n.unit
+}
+
+func (n *Output) Label() string {
+	return n.label
+}
+
+func (n *Output) Description() string {
+	return n.desc
+}
+
+func (n *Output) String() string {
+	ans := "output " + n.name
+	if n.unit != "" {
+		u, _ := MaybeAmbiguousUnitName(n.unit)
+		ans += " in " + u
+	}
+	if n.label != "" {
+		ans += ` as "` + n.label + `"`
+	}
+	if n.desc != "" {
+		ans += ` desc "` + n.desc + `"`
+	}
+	return ans
+}
+
+func joinNodes(nodes []Node) string {
+	strs := make([]string, len(nodes))
+	for k, n := range nodes {
+		strs[k] = n.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+func isNamespaced(name string) bool {
+	return strings.Contains(name, ".")
+}
@@ -68,6 +68,126 @@ func TestMeasureOps(t *testing.T) {
 	}
 }
 
+func TestMeasureCmp(t *testing.T) {
+	cases := []struct {
+		a        string
+		aul      bool
+		b        string
+		bul      bool
+		expected int
+		err      bool
+	}{
+		{a: "1", aul: true, b: "2", bul: true, expected: -1},
+		{a: "110kg", b: "0.11t", expected: 0},
+		{a: "1Gg", b: "999t", expected: 1},
+		{a: "2Mg/10^3m3", b: "2kg/m3", expected: 0},
+		{a: "1kg", b: "1m3", err: true},
+		{a: "1kg", b: "1", bul: true, err: true},
+		{a: "1kg/m3", b: "1Tj/Gg", err: true},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			a, b := mustMV(c.a, c.aul), mustMV(c.b, c.bul)
+			got, err := a.Cmp(b)
+			if c.err {
+				if err == nil {
+					t.Fatalf("expected err, got %d", got)
+				}
+				if a.Equal(b) {
+					t.Fatalf("expected not equal")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.expected {
+				t.Fatalf("expected %d, got %d", c.expected, got)
+			}
+			if a.Equal(b) != (c.expected == 0) {
+				t.Fatalf("expected equal %v", c.expected == 0)
+			}
+		})
+	}
+}
+
+func TestMeasureValueMethods(t *testing.T) {
+	mv := mustMV("-1.255kg", false)
+	if mv.IsZero() || mv.Sign() != -1 || mv.IsUnitless() {
+		t.Fatalf("unexpected IsZero %v, Sign %d, IsUnitless %v", mv.IsZero(), mv.Sign(), mv.IsUnitless())
+	}
+	gots := []string{mv.Abs().String(), mv.Round(2).String(), mv.Truncate(2).String(), mv.Abs().Round(0).String(),
+		mv.Neg().String(), mv.Percent().String()}
+	expected := []string{"1.255kg", "-1.26kg", "-1.25kg", "1kg", "1.255kg", "-0.01255kg"}
+	if !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("expectd: %v, got: %v", expected, gots)
+	}
+	// the unit manager is kept
+	if _, err := mv.Neg().To("t"); err != nil {
+		t.Fatal(err)
+	}
+	if mv.Dimension() != DimMass || mustMV("1kg/m3", false).Dimension() != DimInvalid || mustMV("1", true).Dimension() != DimInvalid {
+		t.Fatalf("unexpected dimension")
+	}
+	if !mv.ConvertibleTo("Gg") || mv.ConvertibleTo("m3") || mv.ConvertibleTo("xx") || mustMV("1", true).ConvertibleTo("kg") {
+		t.Fatalf("unexpected ConvertibleTo")
+	}
+	zero := mustMV("0", true)
+	if !zero.IsZero() || zero.Sign() != 0 || !zero.IsUnitless() {
+		t.Fatalf("unexpected IsZero %v, Sign %d, IsUnitless %v", zero.IsZero(), zero.Sign(), zero.IsUnitless())
+	}
+}
+
+func TestMeasureTemperatureOps(t *testing.T) {
+	cases := []struct {
+		a        string
+		op       string
+		b        string
+		expected string
+	}{
+		{a: "20°C", op: "+", b: "5Δ°C", expected: "25°C"},
+		{a: "20°C", op: "+", b: "5K", expected: "25°C"},
+		{a: "5Δ°C", op: "+", b: "20°C", expected: "25°C"},
+		{a: "20°C", op: "-", b: "9Δ°F", expected: "15°C"},
+		{a: "50°F", op: "+", b: "5Δ°C", expected: "59°F"},
+		{a: "25°C", op: "-", b: "20°C", expected: "5Δ°C"},
+		{a: "25°C", op: "-", b: "32°F", expected: "25Δ°C"},
+		{a: "300K", op: "-", b: "290K", expected: "10K"},
+		{a: "300K", op: "+", b: "10K", expected: "310K"},
+		{a: "300K", op: "-", b: "20°C", expected: "6.85K"},
+		{a: "300K", op: "-", b: "32°F", expected: "26.85K"},
+		{a: "2Δ°C", op: "*", b: "3", expected: "6Δ°C"},
+		{a: "20°C", op: "+", b: "20°C", expected: ""},
+		{a: "5Δ°C", op: "-", b: "20°C", expected: ""},
+		{a: "20°C", op: "*", b: "2", expected: ""},
+		{a: "20°C", op: "/", b: "2", expected: ""},
+		{a: "20°C", op: "+", b: "1kg", expected: ""},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			a := mustMV(c.a, false)
+			b, err := makeMeasureValueFromString(c.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			funs := map[string]func(*MeasureValue) (*MeasureValue, error){"+": a.Add, "-": a.Sub, "*": a.Mul, "/": a.Div}
+			got, err := funs[c.op](b)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expected != got.String() {
+				t.Fatalf("expected: %v, got: %v", c.expected, got.String())
+			}
+		})
+	}
+}
+
 func TestMeasureValueMul2(t *testing.T) {
 	cases := []struct {
 		a        string
@@ -154,4 +274,54 @@ func TestMeasureValueDiv1(t *testing.T) {
 			}
 		})
 	}
+
+	// the division by zero is an error, not a panic
+	for _, b := range []*MeasureValue{mustMV("0", true), mustMV("0m3", false)} {
+		if _, err := mustMV("2m3", false).Div(b); err == nil {
+			t.Fatalf("expected err of division by %s", b)
+		}
+	}
+}
+
+func TestMeasureValueTo(t *testing.T) {
+	cases := []struct {
+		a        string
+		unit     string
+		expected string
+	}{
+		{a: "5Gg", unit: "t", expected: "5000t"},
+		{a: "5Gg", unit: "kg", expected: "5000000kg"},
+		{a: "1kg/m3", unit: "Gg/10^3m3", expected: "0.001Gg/10^3m3"},
+		{a: "5Gg", unit: "m3", expected: ""},
+		{a: "1kg/m3", unit: "kg", expected: ""},
+		{a: "1kg/m3", unit: "m3/kg", expected: ""},
+		// temperatures, the offset is applied both directions
+		{a: "15°C", unit: "K", expected: "288.15K"},
+		{a: "288.15K", unit: "°C", expected: "15°C"},
+		{a: "32°F", unit: "°C", expected: "0°C"},
+		{a: "100°C", unit: "°F", expected: "212°F"},
+		{a: "-40°F", unit: "°C", expected: "-40°C"},
+		{a: "212°F", unit: "K", expected: "373.15K"},
+		{a: "9Δ°F", unit: "Δ°C", expected: "5Δ°C"},
+		{a: "5Δ°C", unit: "K", expected: "5K"},
+		{a: "5Δ°C", unit: "°C", expected: ""},
+		{a: "5°C", unit: "Δ°C", expected: ""},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := mustMV(c.a, false).To(c.unit)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expected != got.String() {
+				t.Fatalf("expected: %v, got: %v", c.expected, got.String())
+			}
+		})
+	}
 }
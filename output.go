@@ -0,0 +1,105 @@
+package calcu
+
+import (
+	"errors"
+	"fmt"
+)
+
+// OutputInfo is an output declared by the script
+type OutputInfo struct {
+	Name string
+	// Unit is the display unit, empty for the string
+	// outputs and the outputs declared without unit.
+	Unit        string
+	Label       string
+	Description string
+	// Value is the measure value in the display unit,
+	// nil for the string outputs.
+	Value *MeasureValue
+	// Str is the value of the string outputs
+	Str string
+}
+
+// resolveOutputs resolves the outputs declared by the script
+// after the evaluation, so that they might be declared ahead
+// of the vars, e.g., at the top of the script. All the invalid
+// outputs are reported together.
+func (i *Interpreter) resolveOutputs(stmts []Stmt) error {
+	var errs []error
+	var outputs []OutputInfo
+	seen := make(map[string]bool)
+	for _, s := range stmts {
+		a, ok := s.Node.(*Output)
+		if !ok {
+			continue
+		}
+		if seen[a.name] {
+			errs = append(errs, fmt.Errorf("line %d: output %s redeclared", s.Line, a.name))
+			continue
+		}
+		seen[a.name] = true
+		out, err := i.resolveOutput(a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", s.Line, err))
+			continue
+		}
+		outputs = append(outputs, out)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, out := range outputs {
+		i.setOutput(out)
+	}
+	return nil
+}
+
+func (i *Interpreter) resolveOutput(a *Output) (OutputInfo, error) {
+	out := OutputInfo{Name: a.name, Unit: a.unit, Label: a.label, Description: a.desc}
+	v, ok := i.lookupVar(a.name)
+	if !ok {
+		return out, fmt.Errorf("found undefined output %s", a.name)
+	}
+	switch v := v.(type) {
+	case *LiteralString:
+		if a.unit != "" {
+			return out, fmt.Errorf("output %s: expect measure value in %s, got %q", a.name, a.unit, v.s)
+		}
+		out.Str = v.s
+	case *MeasureValue:
+		out.Value = v
+		if a.unit != "" {
+			mv, err := v.To(a.unit)
+			if err != nil {
+				return out, fmt.Errorf("output %s: %w", a.name, err)
+			}
+			out.Value = mv
+		}
+	}
+	return out, nil
+}
+
+// setOutput adds the output, or replaces the one of the same
+// name, e.g., the output declared again by the next script.
+func (i *Interpreter) setOutput(out OutputInfo) {
+	if out.Value != nil {
+		delete(i.outstrs, out.Name)
+		i.outvars[out.Name] = out.Value
+	} else {
+		delete(i.outvars, out.Name)
+		i.outstrs[out.Name] = out.Str
+	}
+	for k := range i.outputs {
+		if i.outputs[k].Name == out.Name {
+			i.outputs[k] = out
+			return
+		}
+	}
+	i.outputs = append(i.outputs, out)
+}
+
+// Outputs returns the outputs declared by the scripts in the order
+// of the declarations, the values are also returned by Interpret.
+func (i *Interpreter) Outputs() []OutputInfo {
+	return i.outputs
+}
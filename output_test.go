@@ -0,0 +1,105 @@
+package calcu
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOutputs(t *testing.T) {
+	script := `output GHG in t as "Total GHG emissions" desc "Scope 1, CO2 and CH4";
+output fuel as "Fuel";
+output CH4;
+CO2 = activity_value * 1.1E-04Gg/10^3m3;
+CH4 = activity_value * 2kg/10^3m3;
+GHG = CO2 + CH4 * 28;
+`
+	intrp, err := NewInterpreter(map[string]string{"activity_value": "1(10^3m3)", "fuel": "diesel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outvars["GHG"].String(); got != "0.166t" {
+		t.Fatalf("expected 0.166t, got %s", got)
+	}
+	if _, ok := outvars["CO2"]; ok {
+		t.Fatalf("expected CO2 not in the outputs")
+	}
+	var got []string
+	for _, out := range intrp.Outputs() {
+		v := out.Str
+		if out.Value != nil {
+			v = out.Value.String()
+		}
+		got = append(got, strings.Join([]string{out.Name, out.Unit, out.Label, out.Description, v}, "|"))
+	}
+	expected := []string{
+		"GHG|t|Total GHG emissions|Scope 1, CO2 and CH4|0.166t",
+		"fuel||Fuel||diesel",
+		"CH4||||2kg",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if s := intrp.OutStrings()["fuel"]; s != "diesel" {
+		t.Fatalf("expected diesel, got %s", s)
+	}
+
+	// the outputs declared again are replaced in place
+	if _, err := intrp.Interpret(bytes.NewBufferString(`output GHG in kg;`)); err != nil {
+		t.Fatal(err)
+	}
+	outputs := intrp.Outputs()
+	if len(outputs) != 3 || outputs[0].Value.String() != "166kg" || outputs[0].Label != "" {
+		t.Fatalf("expected GHG replaced by 166kg, got %v", outputs)
+	}
+}
+
+func TestOutputDecls(t *testing.T) {
+	cases := []struct {
+		script string
+		err    string
+	}{
+		{script: "output a;", err: "line 1: found undefined output a"},
+		{script: "a = 1kg;\noutput a;\noutput a in t;", err: "line 3: output a redeclared"},
+		{script: "a = 1kg;\noutput a in m3;", err: "line 2: output a: convert kg to m3 is unsupported"},
+		{script: "a = \"diesel\";\noutput a in t;", err: `line 2: output a: expect measure value in t, got "diesel"`},
+		{script: "output a in xx;", err: "line 1: syntax error"},
+		{script: "output a as b;", err: "line 1: syntax error"},
+		{script: "output a desc \"x\" as \"y\";", err: "line 1: syntax error"},
+		// the errors are reported together
+		{script: "output a;\noutput b;", err: "line 1: found undefined output a\nline 2: found undefined output b"},
+		{script: "a = 1500kg;\noutput a in t as \"A\";"},
+		{script: "a = 1(10^3m3);\noutput a in \"m3\";"},
+		{script: "a = 2t/km;\noutput a in kg/km;"},
+		// the labels and the descriptions might look like units or measure values
+		{script: "x = 1MWh;\noutput x in MWh as \"kWh\";"},
+		{script: "GHG = 1t;\noutput GHG in t desc \"d\";"},
+		{script: "GHG = 1t;\noutput GHG as \"t\" desc \"1kg\";"},
+		// in, as and desc are not reserved outside of the declarations
+		{script: "as = 1kg;\ndesc = as * 2;\noutput desc;"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = intrp.Interpret(bytes.NewBufferString(c.script))
+			if c.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected err %q, got %v", c.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package calcu
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// trie indexes the unit names by bytes, so that the names
+// at the start of the input are found in time proportional
+// to the length of the match rather than the number of units.
+type trie struct {
+	root trieNode
+}
+
+type trieNode struct {
+	b        byte
+	children []*trieNode
+	// name is the name ends at the node, empty if none
+	name string
+}
+
+func (n *trieNode) child(b byte) *trieNode {
+	for _, c := range n.children {
+		if c.b == b {
+			return c
+		}
+	}
+	return nil
+}
+
+func (t *trie) insert(name string) {
+	n := &t.root
+	for i := 0; i < len(name); i++ {
+		c := n.child(name[i])
+		if c == nil {
+			c = &trieNode{b: name[i]}
+			n.children = append(n.children, c)
+		}
+		n = c
+	}
+	n.name = name
+}
+
+// longest returns the longest name at the start of s.
+func (t *trie) longest(s string) (int, string, bool) {
+	n, name, ok := 0, "", false
+	node := &t.root
+	for i := 0; i < len(s); i++ {
+		node = node.child(s[i])
+		if node == nil {
+			break
+		}
+		if node.name != "" {
+			n, name, ok = i+1, node.name, true
+		}
+	}
+	return n, name, ok
+}
+
+// longestFold is longest of the lower case s, the names are
+// expected to be lower case, the length returned is of s.
+func (t *trie) longestFold(s string) (int, string, bool) {
+	n, name, ok := 0, "", false
+	node := &t.root
+	var buf [utf8.UTFMax]byte
+	for i := 0; i < len(s) && node != nil; {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		k := utf8.EncodeRune(buf[:], unicode.ToLower(r))
+		for j := 0; j < k && node != nil; j++ {
+			node = node.child(buf[j])
+		}
+		i += size
+		if node != nil && node.name != "" {
+			n, name, ok = i, node.name, true
+		}
+	}
+	return n, name, ok
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/shopspring/decimal"
 )
@@ -30,8 +33,57 @@ const (
 	DimTime
 	DimLength
 	DimPopulation
+	DimTemperature
+	DimPressure
+	DimAmount
+	DimArea
+	DimPower
+	DimFreight
+	DimPassengerDistance
+	DimCurrency
 )
 
+// Dimensions lists the valid dimensions
+func Dimensions() []Dimension {
+	return []Dimension{DimEnergy, DimMass, DimVolume, DimTime, DimLength, DimPopulation, DimTemperature, DimPressure, DimAmount,
+		DimArea, DimPower, DimFreight, DimPassengerDistance, DimCurrency}
+}
+
+func (d Dimension) String() string {
+	switch d {
+	case DimEnergy:
+		return "Energy"
+	case DimMass:
+		return "Mass"
+	case DimVolume:
+		return "Volume"
+	case DimTime:
+		return "Time"
+	case DimLength:
+		return "Length"
+	case DimPopulation:
+		return "Population"
+	case DimTemperature:
+		return "Temperature"
+	case DimPressure:
+		return "Pressure"
+	case DimAmount:
+		return "Amount"
+	case DimArea:
+		return "Area"
+	case DimPower:
+		return "Power"
+	case DimFreight:
+		return "Freight"
+	case DimPassengerDistance:
+		return "PassengerDistance"
+	case DimCurrency:
+		return "Currency"
+	default:
+		return "Invalid"
+	}
+}
+
 func DimensionFromString(s string) Dimension {
 	d := DimInvalid
 	switch s {
@@ -47,6 +99,22 @@ func DimensionFromString(s string) Dimension {
 		d = DimLength
 	case "Population":
 		d = DimPopulation
+	case "Temperature":
+		d = DimTemperature
+	case "Pressure":
+		d = DimPressure
+	case "Amount":
+		d = DimAmount
+	case "Area":
+		d = DimArea
+	case "Power":
+		d = DimPower
+	case "Freight":
+		d = DimFreight
+	case "PassengerDistance":
+		d = DimPassengerDistance
+	case "Currency":
+		d = DimCurrency
 	}
 	return d
 }
@@ -57,15 +125,21 @@ type Unit interface {
 	Dimension() Dimension
 	IsMeta() bool
 	SiName() string
+	// SiFactors returns the factor and offset to si,
+	// i.e., si = (value + offset) * factor
 	SiFactors() (decimal.Decimal, decimal.Decimal)
 }
 
+// MetaUnit is a unit of one dimension, the si factor is kept
+// as a fraction, i.e., siFactor/siDivisor, so that the units
+// like °F with factor 5/9 are converted exactly.
 type MetaUnit struct {
 	name      string
 	label     string
 	dimension Dimension
 	si        string
 	siFactor  decimal.Decimal
+	siDivisor decimal.Decimal
 	siOffset  decimal.Decimal
 }
 
@@ -90,9 +164,64 @@ func (u *MetaUnit) SiName() string {
 }
 
 func (u *MetaUnit) SiFactors() (decimal.Decimal, decimal.Decimal) {
+	if u.hasDivisor() {
+		return u.siFactor.Div(u.siDivisor), u.siOffset
+	}
 	return u.siFactor, u.siOffset
 }
 
+func (u *MetaUnit) hasDivisor() bool {
+	return !u.siDivisor.IsZero() && !u.siDivisor.Equal(decimal.NewFromInt(1))
+}
+
+// isAbsolute check if the unit has an offset to si, e.g., °C and °F,
+// the values of such units are absolute temperatures, not deltas.
+func (u *MetaUnit) isAbsolute() bool {
+	return !u.siOffset.IsZero()
+}
+
+// isDelta check if the unit is a delta of an absolute unit, e.g., Δ°C
+func (u *MetaUnit) isDelta() bool {
+	return strings.HasPrefix(u.name, deltaPrefix)
+}
+
+const deltaPrefix = "Δ"
+
+// scale converts a delta in the unit to si, the offset is not applied
+func (u *MetaUnit) scale(d decimal.Decimal) decimal.Decimal {
+	d = d.Mul(u.siFactor)
+	if u.hasDivisor() {
+		d = d.Div(u.siDivisor)
+	}
+	return d
+}
+
+// unscale converts a delta in si to the unit, the offset is not applied
+func (u *MetaUnit) unscale(d decimal.Decimal) decimal.Decimal {
+	if u.hasDivisor() {
+		d = d.Mul(u.siDivisor)
+	}
+	return d.Div(u.siFactor)
+}
+
+// siValue converts d in the unit to si
+func siValue(u Unit, d decimal.Decimal) decimal.Decimal {
+	if mu, ok := u.(*MetaUnit); ok {
+		return mu.scale(d.Add(mu.siOffset))
+	}
+	factor, offset := u.SiFactors()
+	return d.Add(offset).Mul(factor)
+}
+
+// fromSiValue converts d in si to the unit
+func fromSiValue(u Unit, d decimal.Decimal) decimal.Decimal {
+	if mu, ok := u.(*MetaUnit); ok {
+		return mu.unscale(d).Sub(mu.siOffset)
+	}
+	factor, offset := u.SiFactors()
+	return d.Div(factor).Sub(offset)
+}
+
 // CompoundUnit represent as Numerator/Denominator
 // Numerator and Denominator should have different
 // dimensions, for example: j/kg, Gg/Tj
@@ -107,8 +236,8 @@ func newCompoundUnit(num, den *MetaUnit) *CompoundUnit {
 	// e.g: energy unit: Tj to J(SI) is 1,000,000,000,000
 	// mass unit: Gg to kg(SI) is 1,000,000, then SI of
 	// Tj/Gg is 1,000,000,000,000/1,000,000 i.e, 1,000,000
-	numFactor := num.siFactor
-	denFactor := den.siFactor
+	numFactor, _ := num.SiFactors()
+	denFactor, _ := den.SiFactors()
 	return &CompoundUnit{
 		Numerator:   num,
 		Denominator: den,
@@ -146,7 +275,7 @@ func (u *CompoundUnit) SiFactors() (decimal.Decimal, decimal.Decimal) {
 }
 
 func (u *CompoundUnit) isNumDenSameDim() bool {
-	return u.Numerator.Dimension() == u.Denominator.Dimension()
+	return isConvertible(u.Numerator, u.Denominator)
 }
 
 func (u *CompoundUnit) IsMulCancelable(other *CompoundUnit) bool {
@@ -156,17 +285,57 @@ func (u *CompoundUnit) IsMulCancelable(other *CompoundUnit) bool {
 		// e.g., 1kg/kg * 1kg/kg should result 1kg/kg(not 1kg^2/kg^2 in strict math)
 		return false
 	}
-	a := u.Numerator.Dimension() == other.Denominator.Dimension()
-	b := u.Denominator.Dimension() == other.Numerator.Dimension()
+	a := isConvertible(u.Numerator, other.Denominator)
+	b := isConvertible(u.Denominator, other.Numerator)
 	return a && b
 }
 
 func (u *CompoundUnit) IsDivCancelable(other *CompoundUnit) bool {
-	a := u.Numerator.Dimension() == other.Numerator.Dimension()
-	b := u.Denominator.Dimension() == other.Denominator.Dimension()
+	a := isConvertible(u.Numerator, other.Numerator)
+	b := isConvertible(u.Denominator, other.Denominator)
 	return a && b
 }
 
+// isConvertible check if the meta units convert by the si factors,
+// i.e., of the same dimension and si. The currencies are of the same
+// dimension, but each is its own si, they convert by exchange rates.
+func isConvertible(u, ou *MetaUnit) bool {
+	return u.dimension == ou.dimension && u.si == ou.si
+}
+
+// isSameDimension check if the units are convertible, i.e.,
+// both are meta units of the same dimension, or both are
+// compound units with the same dimensions of Numerator and
+// Denominator.
+func isSameDimension(u, ou Unit) bool {
+	if u.IsMeta() != ou.IsMeta() {
+		return false
+	}
+	if u.IsMeta() {
+		mu, omu := u.(*MetaUnit), ou.(*MetaUnit)
+		if !isConvertible(mu, omu) {
+			return false
+		}
+		// an absolute temperature is not convertible
+		// to a delta temperature, and vice versa.
+		if (mu.isAbsolute() && omu.isDelta()) || (mu.isDelta() && omu.isAbsolute()) {
+			return false
+		}
+		return true
+	}
+	cu1, cu2 := u.(*CompoundUnit), ou.(*CompoundUnit)
+	return cu1.IsDivCancelable(cu2)
+}
+
+// canonicalUnit returns the canonical name of the
+// unit name or alias, e.g., t of tonnes.
+func canonicalUnit(um UnitManager, name string) string {
+	if u, ok := um.GetByName(name); ok {
+		return u.Name()
+	}
+	return name
+}
+
 func MaybeAmbiguousUnitName(name string) (string, bool) {
 	// if the first char of unit
 	// is a digit, we use brackets
@@ -181,19 +350,126 @@ func MaybeAmbiguousUnitName(name string) (string, bool) {
 //go:embed unit.csv
 var unitAsset embed.FS
 
+// siPrefix is a SI prefix of the prefixable units, e.g., the k of kJ
+type siPrefix struct {
+	symbol string
+	name   string
+	exp    int32
+}
+
+// siPrefixes are the prefixes generated for the prefixable units,
+// deca, hecto and deci are left out for they are rarely used and
+// are easily mistaken for units, e.g., d for day, h for hour.
+var siPrefixes = []siPrefix{
+	{"P", "Peta", 15},
+	{"T", "Tera", 12},
+	{"G", "Giga", 9},
+	{"M", "Mega", 6},
+	{"k", "Kilo", 3},
+	{"c", "Centi", -2},
+	{"m", "Milli", -3},
+	{"µ", "Micro", -6},
+	{"n", "Nano", -9},
+}
+
+// withPrefix returns the prefixed unit of u, e.g., kJ of J
+func (u *MetaUnit) withPrefix(p siPrefix) *MetaUnit {
+	return &MetaUnit{
+		name:      p.symbol + u.name,
+		label:     p.name + strings.ToLower(u.label),
+		dimension: u.dimension,
+		si:        u.si,
+		siFactor:  u.siFactor.Shift(p.exp),
+		siDivisor: u.siDivisor,
+		siOffset:  u.siOffset,
+	}
+}
+
+// prefixedUnits generates the prefixed units of the prefixable ones,
+// the units in the catalogue take precedence over the generated ones,
+// e.g., kg of the catalogue rather than the one generated from g, or
+// the minute rather than a milli-inch if the inch were prefixable.
+func prefixedUnits(catalogue map[string]Unit, prefixable []*MetaUnit) []*MetaUnit {
+	var ans []*MetaUnit
+	for _, u := range prefixable {
+		for _, p := range siPrefixes {
+			pu := u.withPrefix(p)
+			if _, ok := catalogue[pu.name]; ok {
+				continue
+			}
+			ans = append(ans, pu)
+		}
+	}
+	return ans
+}
+
 type staticum struct {
 	m map[string]Unit
 
-	dimMUnits map[Dimension][]*MetaUnit
+	// the units of the catalogue, i.e., without
+	// the generated prefixed units.
+	catalogue map[Dimension][]*MetaUnit
+
+	// the units resolved by the unit expressions, e.g.,
+	// kg/t, ft2, by the canonical name only, so the spellings
+	// of the same unit, e.g., kg/(m3), don't grow it
+	derived sync.Map
+	// the units of the catalogue to derive the others from
+	powers   map[powerKey]powerRef
+	products map[[2]Dimension]productRef
+
+	// aliases of the meta units, e.g., tonne of t, and the
+	// long names and case-folded names if they are enabled
+	aliases map[string]*MetaUnit
+	// the meta unit names and aliases, and the aliases
+	// to match case-insensitively for the peek operation
+	metaTrie trie
+	foldTrie trie
+	folded   bool
+}
+
+// UnitOption configures the unit manager created by NewUnitManager
+type UnitOption func(*unitConfig)
+
+type unitConfig struct {
+	caseInsensitive bool
+	longNames       bool
+}
+
+// WithCaseInsensitiveUnits matches the units regardless of case, e.g.,
+// KWH for kWh, the names that differ only by case, e.g., mW and MW,
+// are still matched exactly.
+func WithCaseInsensitiveUnits() UnitOption {
+	return func(c *unitConfig) {
+		c.caseInsensitive = true
+	}
+}
 
-	ulens []int
-	names []string
+// WithLongUnitNames matches the units by the labels and
+// their plural forms, e.g., Kilogram and Kilograms for kg.
+func WithLongUnitNames() UnitOption {
+	return func(c *unitConfig) {
+		c.longNames = true
+	}
 }
 
-func newStaticUintManager() UnitManager {
+// NewUnitManager creates a unit manager of the builtin units, the
+// StdUm can be replaced by one with the options, e.g.,
+//
+//	calcu.StdUm = calcu.NewUnitManager(calcu.WithCaseInsensitiveUnits())
+func NewUnitManager(opts ...UnitOption) UnitManager {
+	return newStaticUintManager(opts...)
+}
+
+func newStaticUintManager(opts ...UnitOption) UnitManager {
+	var cfg unitConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	m := make(map[string]Unit)
-	dimMUnits := make(map[Dimension][]*MetaUnit)
-	var names []string
+	catalogue := make(map[Dimension][]*MetaUnit)
+	var prefixable []*MetaUnit
+	aliases := make(map[string]*MetaUnit)
 
 	f, _ := unitAsset.Open("unit.csv")
 	rd := csv.NewReader(f)
@@ -207,7 +483,13 @@ func newStaticUintManager() UnitManager {
 			continue // skip header row
 		}
 		dimension := DimensionFromString(record[2])
-		siFactor, _ := decimal.NewFromString(record[4])
+		// the factor might be a fraction, e.g., 5/9
+		factor, divisor, ok := strings.Cut(record[4], "/")
+		if !ok {
+			divisor = "1"
+		}
+		siFactor, _ := decimal.NewFromString(factor)
+		siDivisor, _ := decimal.NewFromString(divisor)
 		siOffset, _ := decimal.NewFromString(record[5])
 		u := MetaUnit{
 			name:      record[0],
@@ -215,62 +497,97 @@ func newStaticUintManager() UnitManager {
 			dimension: dimension,
 			si:        record[3],
 			siFactor:  siFactor,
+			siDivisor: siDivisor,
 			siOffset:  siOffset,
 		}
 		m[u.name] = &u
-		dimMUnits[u.dimension] = append(dimMUnits[u.dimension], &u)
-		names = append(names, u.name)
-		if s, ok := MaybeAmbiguousUnitName(u.name); ok {
-			names = append(names, s)
+		catalogue[u.dimension] = append(catalogue[u.dimension], &u)
+		if ok, _ := strconv.ParseBool(record[6]); ok {
+			prefixable = append(prefixable, &u)
 		}
-	}
-
-	// permutations for dimensions to build compound units
-	dims := []Dimension{DimEnergy, DimMass, DimVolume, DimTime, DimLength, DimPopulation}
-	var arr []Dimension
-	var permutations [][]Dimension
-	// we only need permutation with length of 2
-	// assuming the first is num, and the second is den
-	permute(arr, &permutations, dims, 2)
-	// generate all possible compound units
-	for _, p := range permutations {
-		num, den := p[0], p[1]
-		nums := dimMUnits[num]
-		dens := dimMUnits[den]
-		for i := 0; i < len(nums); i++ {
-			for j := 0; j < len(dens); j++ {
-				cu := newCompoundUnit(nums[i], dens[j])
-				names = append(names, cu.Name())
-				if s, ok := MaybeAmbiguousUnitName(cu.Name()); ok {
-					names = append(names, s)
-				}
-				m[cu.Name()] = cu
+		for _, alias := range strings.Split(record[7], "|") {
+			if alias != "" {
+				aliases[alias] = &u
 			}
 		}
 	}
-	// order names by length for the peek operation
-	sort.Slice(names, func(i, j int) bool {
-		return len(names[i]) > len(names[j])
-	})
-	ulens := make([]int, len(names))
-	for i, name := range names {
-		ulens[i] = len(name)
+	for _, u := range prefixedUnits(m, prefixable) {
+		m[u.name] = u
+	}
+	aliases = extendAliases(m, aliases, cfg)
+	su := &staticum{
+		m:         m,
+		catalogue: catalogue,
+		aliases:   aliases,
+		folded:    cfg.caseInsensitive,
+	}
+	var units []*MetaUnit
+	for _, dim := range Dimensions() {
+		units = append(units, catalogue[dim]...)
+	}
+	su.indexDerivable(units)
+	for name, u := range m {
+		if _, ok := u.(*MetaUnit); ok {
+			su.metaTrie.insert(name)
+		}
 	}
-	return &staticum{names: names, ulens: ulens, m: m, dimMUnits: dimMUnits}
+	for alias := range aliases {
+		su.metaTrie.insert(alias)
+		if su.folded {
+			su.foldTrie.insert(alias)
+		}
+	}
+	return su
 }
 
-func permute(arr []Dimension, ans *[][]Dimension, dims []Dimension, depth int) {
-	if len(arr) == depth {
-		dst := make([]Dimension, len(arr))
-		copy(dst, arr)
-		*ans = append(*ans, dst)
-		return
+// extendAliases adds the long names and the case-folded names
+// to the aliases by the config, the aliases never override a
+// unit, and the folded names of different units are left out
+// as they are ambiguous, e.g., mw of mW and MW.
+func extendAliases(m map[string]Unit, aliases map[string]*MetaUnit, cfg unitConfig) map[string]*MetaUnit {
+	ans := make(map[string]*MetaUnit)
+	for alias, u := range aliases {
+		ans[alias] = u
 	}
-	for _, dim := range dims {
-		arr = append(arr, dim)
-		permute(arr, ans, dims, depth)
-		arr = arr[:len(arr)-1]
+	if cfg.longNames {
+		for _, u := range m {
+			if mu, ok := u.(*MetaUnit); ok {
+				ans[mu.label] = mu
+				ans[mu.label+"s"] = mu
+			}
+		}
+	}
+	for name := range ans {
+		if _, ok := m[name]; ok {
+			delete(ans, name)
+		}
+	}
+	if !cfg.caseInsensitive {
+		return ans
+	}
+	folded := make(map[string]*MetaUnit)
+	ambiguous := make(map[string]bool)
+	fold := func(name string, u *MetaUnit) {
+		key := strings.ToLower(name)
+		if fu, ok := folded[key]; ok && fu != u {
+			ambiguous[key] = true
+		}
+		folded[key] = u
+	}
+	for name, u := range m {
+		if mu, ok := u.(*MetaUnit); ok {
+			fold(name, mu)
+		}
+	}
+	for alias, u := range ans {
+		fold(alias, u)
+	}
+	for key, u := range folded {
+		if !ambiguous[key] {
+			ans[key] = u
+		}
 	}
+	return ans
 }
 
 func (su *staticum) dimension(s string) (Dimension, bool) {
@@ -285,47 +602,116 @@ func (su *staticum) dimension(s string) (Dimension, bool) {
 	return DimInvalid, false
 }
 
+// inlinePeeker is of the unit managers peeking the units inline
+// of an expression, see staticum.peekInline.
+type inlinePeeker interface {
+	peekInline(s string, decl bool) (int, bool)
+}
+
+// Peek peeks the unit expression, e.g., kg, kg/t, kg/(t.km) or W.m-2, see
+// unitScanner. The unit must be followed by a separator, otherwise, the
+// `m` in `me` or in `m = 1` would be taken as a unit.
 func (su *staticum) Peek(s string) (int, bool) {
-	for i, name := range su.names {
-		n := su.ulens[i]
-		if len(s) < n {
+	return su.peek(s, true, false)
+}
+
+// peekInline peeks the unit inline of an expression, the spaces
+// do not multiply, e.g., the `t` of 2kg t is a var, and the keywords
+// of the declarations are separators if decl, e.g., the range of
+// default 1kg range [0kg, 1t].
+func (su *staticum) peekInline(s string, decl bool) (int, bool) {
+	return su.peek(s, false, decl)
+}
+
+func (su *staticum) peek(s string, spaced, decl bool) (int, bool) {
+	var ends []int
+	var cands [][]unitTerm
+	su.scanUnitExpr(s, true, spaced, func(end int, terms []unitTerm) {
+		ends = append(ends, end)
+		cands = append(cands, terms)
+	})
+	// the longest one resolves, e.g., kg of kg/xyz
+	for k := len(ends) - 1; k >= 0; k-- {
+		if rest := s[ends[k]:]; !startWithSeparator(rest) && !(decl && startWithDeclKeyword(rest)) {
 			continue
 		}
-		a, b := s[:n], ""
-		if len(s) > n {
-			b = s[n:]
-		}
-		// consider the following char to
-		// avoid mistake, i.e., the unit
-		// token should only appear before
-		// a separator char or end of line.
-		// not in front of a char. for example,
-		// consider unit Meter(m), without
-		// check the following char, we might
-		// treat the `m` in word `me` as a unit
-		// or the `m` in `m = 1` will be treated
-		// as a unit as well.
-		if a == name && startWithSeparator(b) {
-			return n, true
+		if _, ok := su.resolveTerms(cands[k]); ok {
+			return ends[k], true
 		}
 	}
 	return 0, false
 }
 
+// peekMeta peeks the longest meta unit or alias at the start of s
+func (su *staticum) peekMeta(s string) (int, *MetaUnit, bool) {
+	n, name, ok := su.metaTrie.longest(s)
+	if su.folded {
+		// only the aliases are case-folded, e.g., MM is not mm
+		if fn, fname, fok := su.foldTrie.longestFold(s); fok && fn > n {
+			n, name, ok = fn, fname, true
+		}
+	}
+	if !ok {
+		return 0, nil, false
+	}
+	u, _ := su.metaUnit(name)
+	return n, u, true
+}
+
 func (su *staticum) IsUnit(s string) bool {
-	_, ok := su.m[s]
+	_, ok := su.GetByName(s)
 	return ok
 }
 
+// GetByName looks up the unit by name or alias, the unit expressions
+// are resolved on demand, e.g., kg/t, kg/(t.km) or W.m-2. The unit
+// returned is named canonically, e.g., t for tonnes, W/m2 for W.m-2.
 func (su *staticum) GetByName(name string) (Unit, bool) {
-	u, ok := su.m[name]
-	return u, ok
+	if u, ok := su.m[name]; ok {
+		return u, true
+	}
+	if u, ok := su.alias(name); ok {
+		return u, true
+	}
+	if u, ok := su.derived.Load(name); ok {
+		return u.(Unit), true
+	}
+	var terms []unitTerm
+	su.scanUnitExpr(name, false, true, func(end int, ts []unitTerm) {
+		if end == len(name) {
+			terms = ts
+		}
+	})
+	if terms == nil {
+		return nil, false
+	}
+	return su.resolveTerms(terms)
+}
+
+func (su *staticum) alias(name string) (*MetaUnit, bool) {
+	if u, ok := su.aliases[name]; ok {
+		return u, true
+	}
+	if su.folded {
+		u, ok := su.aliases[strings.ToLower(name)]
+		return u, ok
+	}
+	return nil, false
+}
+
+func (su *staticum) metaUnit(name string) (*MetaUnit, bool) {
+	if u, ok := su.m[name].(*MetaUnit); ok {
+		return u, true
+	}
+	return su.alias(name)
 }
 
+// ListMetaUnitsByDims lists the units of the catalogue, the
+// generated prefixed units are not listed, e.g., kJ of J.
 func (su *staticum) ListMetaUnitsByDims(dims ...Dimension) ([]*MetaUnit, error) {
 	var ans []*MetaUnit
 	for _, dim := range dims {
-		ans = append(ans, su.dimMUnits[dim]...)
+		ans = append(ans, su.catalogue[dim]...)
 	}
 	sort.SliceStable(ans, func(i, j int) bool {
 		return ans[i].label < ans[j].label
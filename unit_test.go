@@ -1,25 +1,647 @@
 package calcu
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
-)
 
-func TestDimensionPermutations(t *testing.T) {
-	// we only need permutation with length of 2
-	// assuming the first is num, and the second is den
-	dims := []Dimension{DimEnergy, DimMass}
-	var arr []Dimension
-	var permutations [][]Dimension
-	permute(arr, &permutations, dims, 2)
-	want, got := 4, len(permutations)
-	if want != len(permutations) {
-		t.Errorf("permute: got %q, want %q", got, want)
-	}
-}
+	"github.com/shopspring/decimal"
+)
 
 func TestStaticUnitManager(t *testing.T) {
 	um := newStaticUintManager()
 	sd := um.(*staticum)
-	fmt.Println(len(sd.names))
+	fmt.Println(len(sd.m))
+}
+
+func TestDimensionString(t *testing.T) {
+	for _, d := range Dimensions() {
+		if got := DimensionFromString(d.String()); got != d {
+			t.Errorf("dimension %s: got %v, want %v", d, got, d)
+		}
+	}
+}
+
+func TestUnitCatalogue(t *testing.T) {
+	cases := []struct {
+		a        string
+		unit     string
+		expected string
+	}{
+		{a: "1ha", unit: "m2", expected: "10000m2"},
+		{a: "1km2", unit: "ha", expected: "100ha"},
+		{a: "1acre", unit: "m2", expected: "4046.8564224m2"},
+		{a: "1MW", unit: "kW", expected: "1000kW"},
+		{a: "1MWh", unit: "Gj", expected: "3.6Gj"},
+		{a: "1bar", unit: "kPa", expected: "100kPa"},
+		{a: "1MPa", unit: "bar", expected: "10bar"},
+		{a: "1yr", unit: "d", expected: "365d"},
+		{a: "2h", unit: "min", expected: "120min"},
+		{a: "1d", unit: "s", expected: "86400s"},
+		{a: "1000kg.km", unit: "t.km", expected: "1t.km"},
+		{a: "100passenger.mi", unit: "passenger.km", expected: "160.9344passenger.km"},
+		{a: "0.5kg/kWh", unit: "t/MWh", expected: "0.5t/MWh"},
+		{a: "1ha", unit: "kW", expected: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.a+"_"+c.unit, func(t *testing.T) {
+			got, err := mustMV(c.a, false).To(c.unit)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+
+	// freight activity times the factor per t.km is mass
+	got, err := mustMV("1000t.km", false).Mul(mustMV("0.1kg/t.km", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "100kg" {
+		t.Fatalf("expected 100kg, got %v", got)
+	}
+}
+
+func TestSIPrefixes(t *testing.T) {
+	cases := []struct {
+		a        string
+		unit     string
+		expected string
+	}{
+		{a: "1kJ", unit: "J", expected: "1000J"},
+		{a: "1MJ", unit: "kj", expected: "1000kj"},
+		{a: "1TJ", unit: "Tj", expected: "1Tj"},
+		{a: "1PJ", unit: "TJ", expected: "1000TJ"},
+		{a: "1GWh", unit: "MWh", expected: "1000MWh"},
+		{a: "1000µg", unit: "mg", expected: "1mg"},
+		{a: "1Gg", unit: "kt", expected: "1kt"},
+		{a: "1mm", unit: "m", expected: "0.001m"},
+		{a: "1Mm", unit: "km", expected: "1000km"},
+		{a: "1nm", unit: "µm", expected: "0.001µm"},
+		{a: "1kmol", unit: "mmol", expected: "1000000mmol"},
+		{a: "1mbar", unit: "hPa", expected: ""},
+		{a: "1mbar", unit: "Pa", expected: "100Pa"},
+		{a: "1kPa", unit: "MPa", expected: "0.001MPa"},
+		{a: "1MJ/kg", unit: "kJ/g", expected: "1kJ/g"},
+	}
+	for _, c := range cases {
+		t.Run(c.a+"_"+c.unit, func(t *testing.T) {
+			mv, err := NewMeasureValueFromString(c.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := mv.To(c.unit)
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+
+	u, ok := StdUm.GetByName("kJ")
+	if !ok || u.Label() != "Kilojoule" || u.Dimension() != DimEnergy {
+		t.Fatalf("unexpected kJ: %+v", u)
+	}
+	// the units not prefixable are not prefixed
+	for _, name := range []string{"kft", "Mcal", "kkg", "m°C", "kUSD"} {
+		if StdUm.IsUnit(name) {
+			t.Fatalf("unexpected unit %s", name)
+		}
+	}
+	// the generated units are not listed
+	units, _ := StdUm.ListMetaUnitsByDims(DimEnergy)
+	for _, u := range units {
+		if u.Name() == "kJ" {
+			t.Fatalf("unexpected listed unit %s", u.Name())
+		}
+	}
+}
+
+func TestPrefixedUnitsConflict(t *testing.T) {
+	one := decimal.NewFromInt(1)
+	in := &MetaUnit{name: "in", label: "Inch", siFactor: one, siDivisor: one}
+	m := &MetaUnit{name: "m", label: "Meter", siFactor: one, siDivisor: one}
+	min := &MetaUnit{name: "min", label: "Minute", siFactor: one, siDivisor: one}
+	catalogue := map[string]Unit{"in": in, "m": m, "min": min, "km": m}
+	got := make(map[string]*MetaUnit)
+	for _, u := range prefixedUnits(catalogue, []*MetaUnit{in, m}) {
+		got[u.name] = u
+	}
+	// the catalogue units are not overridden
+	if _, ok := got["min"]; ok {
+		t.Fatalf("unexpected generated min")
+	}
+	if _, ok := got["km"]; ok {
+		t.Fatalf("unexpected generated km")
+	}
+	if u, ok := got["mm"]; !ok || u.label != "Millimeter" || u.siFactor.String() != "0.001" {
+		t.Fatalf("unexpected generated mm: %+v", u)
+	}
+}
+
+func TestUnitAliases(t *testing.T) {
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `2tonnes + 500kg`, expected: "2500kg"},
+		{expr: `1000L`, expected: "1000ltr"},
+		{expr: `3m^3 + 1m3`, expected: "4m3"},
+		{expr: `2 hours + 30min`, expected: "9000s"},
+		{expr: `2 hours * 3`, expected: "6h"},
+		{expr: `0.5kg/tonne * 10t`, expected: "5kg"},
+		{expr: `1000kg/m^3`, expected: "1000kg/m3"},
+		{expr: `25degC`, expected: "25°C"},
+		{expr: `1"tonne"`, expected: "1t"},
+		{expr: `1KWH`},
+		{expr: `1tonnesx`},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			intrp, err := NewInterpreter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outvars, err := intrp.Interpret(bytes.NewBufferString("a = " + c.expr + ";\nprint(a);"))
+			if c.expected == "" {
+				if err == nil {
+					t.Fatalf("expected err, got %v", outvars["a"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outvars["a"].String(); got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+		})
+	}
+
+	for name, expected := range map[string]string{"tonnes": "t", "litre": "ltr", "kg/tonne": "kg/t", "hr/yr": "h/yr"} {
+		if u, ok := StdUm.GetByName(name); !ok || u.Name() != expected {
+			t.Fatalf("%s: expected %s, got %v", name, expected, u)
+		}
+	}
+	if mv, err := NewMeasureValueFromString("5tonnes"); err != nil || mv.String() != "5t" {
+		t.Fatalf("expected 5t, got %v, %v", mv, err)
+	}
+	if mv := MakeMeasureValueFromDecimal(decimal.NewFromInt(5), "litres"); mv.String() != "5ltr" {
+		t.Fatalf("expected 5ltr, got %v", mv)
+	}
+}
+
+func TestUnitManagerOptions(t *testing.T) {
+	um := NewUnitManager(WithCaseInsensitiveUnits(), WithLongUnitNames())
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "KWH", expected: "kWh"},
+		{name: "kwh", expected: "kWh"},
+		{name: "TONNES", expected: "t"},
+		{name: "Kilograms", expected: "kg"},
+		{name: "kilogram", expected: "kg"},
+		{name: "Kilowatt hours", expected: "kWh"},
+		{name: "KG/TONNE", expected: "kg/t"},
+		{name: "Mm", expected: "Mm"},
+		{name: "mm", expected: "mm"},
+		// mw is either mW or MW
+		{name: "mw"},
+		{name: "MM"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, ok := um.GetByName(c.name)
+			if c.expected == "" {
+				if ok {
+					t.Fatalf("expected no unit, got %s", u.Name())
+				}
+				return
+			}
+			if !ok || u.Name() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, u)
+			}
+		})
+	}
+	if n, ok := um.Peek("Kilowatt hours + 1"); !ok || n != len("Kilowatt hours") {
+		t.Fatalf("unexpected peek: %d, %v", n, ok)
+	}
+	// the default is case sensitive
+	if StdUm.IsUnit("KWH") || StdUm.IsUnit("Kilograms") {
+		t.Fatalf("unexpected units of StdUm")
+	}
+
+	std := StdUm
+	StdUm = um
+	defer func() { StdUm = std }()
+	intrp, err := NewInterpreter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(`a = 500 kilowatt hours * 2;
+b = 1 KWH;
+print(a, b);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := outvars["a"].String(), outvars["b"].String(); a != "1000kWh" || b != "1kWh" {
+		t.Fatalf("expected 1000kWh and 1kWh, got %s and %s", a, b)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	cases := []struct {
+		s  string
+		n  int
+		ok bool
+	}{
+		{"kg", 2, true},
+		{"kg;", 2, true},
+		{"kg/t", 4, true},
+		{"kg/t.km + 1", 7, true},
+		{"Gg/10^3m3", 9, true},
+		{"(Gg/10^3m3)", 11, true},
+		{"10^3m3)", 6, true},
+		{"m", 1, true},
+		{"me", 0, false},
+		{"m = 1", 0, false},
+		{"mm", 2, true},
+		{"mmol/kg", 7, true},
+		{"min", 3, true},
+		{"mi", 2, true},
+		{"t.km", 4, true},
+		{"t.kmx", 0, false},
+		{"tonnes", 6, true},
+		{"kg/tonne", 8, true},
+		{"°C", 3, true},
+		{"Δ°C + 1", 5, true},
+		{"µg", 3, true},
+		{"kWh*2", 3, true},
+		{"passenger.km", 12, true},
+		{"N.m/kg", 6, true},
+		{"d.m.", 4, true},
+		{"USD/kg", 6, true},
+		{"kUSD", 0, false},
+		{"Tj/Gg", 5, true},
+		{"h", 1, true},
+		{"hours", 5, true},
+		{"x", 0, false},
+		{"", 0, false},
+		{"%", 0, false},
+		{"kg%", 2, true},
+		{"(10^3m3/t)", 10, true},
+		{"(10^3m3)/t", 10, true},
+		{"kg/(t)", 6, true},
+		{"kg/(10^3m3) * 2", 11, true},
+		{"10^3m3/t", 8, true},
+		{"kg/ltr*2", 6, true},
+		{"kg/xyz", 2, true},
+		{"(kg)", 4, true},
+		{"(kg/t)", 6, true},
+		{"kg/(t.km)", 9, true},
+		{"kg m-3", 6, true},
+		{"kg m-3;", 6, true},
+		{"W.m-2", 5, true},
+		{"W·m^-2 * 2", 7, true},
+		{"m-3", 1, true},
+		{"m^2 + 1", 3, true},
+		{"kg * t", 2, true},
+		{"kg s", 4, true},
+		{"kg m", 4, true},
+		{"kgCO2e/kWh", 10, true},
+	}
+	for _, c := range cases {
+		n, ok := StdUm.Peek(c.s)
+		if n != c.n || ok != c.ok {
+			t.Fatalf("peek %q: expected %d %v, got %d %v", c.s, c.n, c.ok, n, ok)
+		}
+	}
+
+	// inline of an expression, the spaces do not multiply, and the
+	// keywords of the declarations are separators of the declarations
+	inline := []struct {
+		s    string
+		decl bool
+		n    int
+		ok   bool
+	}{
+		{"kg;", false, 2, true},
+		{"kg m-3;", false, 0, false},
+		{"kg t;", false, 0, false},
+		{"kg/t, 1", false, 4, true},
+		{"kg range [0kg, 1t]", false, 0, false},
+		{"kg range [0kg, 1t]", true, 2, true},
+		{"t as \"Total\"", true, 1, true},
+		{"kg]", false, 2, true},
+	}
+	for _, c := range inline {
+		n, ok := StdUm.(*staticum).peekInline(c.s, c.decl)
+		if n != c.n || ok != c.ok {
+			t.Fatalf("peek inline %q: expected %d %v, got %d %v", c.s, c.n, c.ok, n, ok)
+		}
+	}
+
+	um := NewUnitManager(WithCaseInsensitiveUnits()).(*staticum)
+	for s, expected := range map[string]string{"KWH;": "kWh", "Tonnes": "t", "kg": "kg", "ΔDEGC": "Δ°C"} {
+		n, u, ok := um.peekMeta(s)
+		if !ok || u.name != expected || n != len(strings.TrimSuffix(s, ";")) {
+			t.Fatalf("peek %q: expected %s, got %d %v", s, expected, n, u)
+		}
+	}
+	// MM is either mm or Mm
+	if n, ok := um.Peek("MM;"); ok {
+		t.Fatalf("peek MM: expected no unit, got %d", n)
+	}
+}
+
+func TestCompoundUnits(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "kg/t", expected: "kg/t"},
+		{name: "kg/tonne", expected: "kg/t"},
+		{name: "kg/(10^3m3)", expected: "kg/10^3m3"},
+		{name: "(10^3m3/t)", expected: "10^3m3/t"},
+		{name: "kJ/µg", expected: "kJ/µg"},
+		{name: "USD/kWh", expected: "USD/kWh"},
+		{name: "kg/t/h", expected: "kg/t.h"},
+		{name: "kg/"},
+		{name: "/kg"},
+		{name: "kg/xyz"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, ok := StdUm.GetByName(c.name)
+			if c.expected == "" {
+				if ok {
+					t.Fatalf("expected no unit, got %s", u.Name())
+				}
+				return
+			}
+			if !ok || u.Name() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, u)
+			}
+		})
+	}
+	// the resolved units are cached
+	u1, _ := StdUm.GetByName("kg/tonne")
+	u2, _ := StdUm.GetByName("kg/t")
+	if u1 != u2 {
+		t.Fatalf("expected the cached unit")
+	}
+
+	intrp, err := NewInterpreter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(`a = 1000kg/(10^3m3) * 2m3;
+print(a);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outvars["a"].String(); got != "2kg" {
+		t.Fatalf("expected 2kg, got %s", got)
+	}
+}
+
+func TestUnitExpressions(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "kg/(t.km)", expected: "kg/t.km"},
+		{name: "kgCO2e/kWh", expected: "kg/kWh"},
+		{name: "g/m2", expected: "g/m2"},
+		{name: "W.m-2", expected: "W/m2"},
+		{name: "W·m^-2", expected: "W/m2"},
+		{name: "kg m-3", expected: "kg/m3"},
+		{name: "kg*m^-3", expected: "kg/m3"},
+		{name: "m^2", expected: "m2"},
+		{name: "m.m", expected: "m2"},
+		{name: "km^2", expected: "km2"},
+		{name: "ft2", expected: "ft2"},
+		{name: "m2^2", expected: "m4"},
+		{name: "m2.m", expected: "m3"},
+		{name: "km2^2", expected: "km4"},
+		{name: "m3/m2", expected: "m3/m2"},
+		{name: "t/(h.km)", expected: "t/h.km"},
+		{name: "kg/t/km", expected: "kg/t.km"},
+		{name: "kg/(t/h)", expected: "kg.h/t"},
+		{name: "m-2"},
+		{name: "W/m2.K", expected: "W/m2.K"},
+		{name: "kg*t", expected: "kg.t"},
+		{name: "°C.m"},
+		{name: "kg^0"},
+		{name: "kg/"},
+		{name: "(kg"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, ok := StdUm.GetByName(c.name)
+			if c.expected == "" {
+				if ok {
+					t.Fatalf("expected no unit, got %s", u.Name())
+				}
+				return
+			}
+			if !ok || u.Name() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, u)
+			}
+		})
+	}
+
+	conversions := []struct {
+		a        string
+		unit     string
+		expected string
+	}{
+		{a: "1ft2", unit: "m2", expected: "0.09290304m2"},
+		{a: "2m2.m", unit: "ltr", expected: "2000ltr"},
+		{a: "1km2^2", unit: "m2^2", expected: "1000000000000m4"},
+		{a: "1000cm3", unit: "ltr", expected: "1ltr"},
+		{a: "1km3", unit: "10^9m3", expected: "1(10^9m3)"},
+		{a: "1000kg.mi", unit: "t.km", expected: "1.609344t.km"},
+		{a: "5 kg m-3", unit: "g/ltr", expected: "5g/ltr"},
+		{a: "2 W·m^-2", unit: "kW/km2", expected: "2000kW/km2"},
+		{a: "1(kg/(t.km))", unit: "g/kg.km", expected: "1g/kg.km"},
+		{a: "1t/(s.km)", unit: "kg/(m.s)", expected: "1kg/m.s"},
+	}
+	for _, c := range conversions {
+		t.Run(c.a+"_"+c.unit, func(t *testing.T) {
+			mv, err := NewMeasureValueFromString(c.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := mv.To(c.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != c.expected {
+				t.Fatalf("expected %s, got %v", c.expected, got)
+			}
+		})
+	}
+
+	intrp, err := NewInterpreter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outvars, err := intrp.Interpret(bytes.NewBufferString(`a = 5m-3m;
+b = "2 kg m-3" * 10m3;
+c = 0.5kgCO2e/t * 10t;
+print(a, b, c);`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gots := []string{outvars["a"].String(), outvars["b"].String(), outvars["c"].String()}
+	if expected := []string{"2m", "20kg", "5kg"}; !reflect.DeepEqual(expected, gots) {
+		t.Fatalf("expected %v, got %v", expected, gots)
+	}
+
+	// a var after a space is not multiplied to the unit
+	if _, err := intrp.Interpret(bytes.NewBufferString("t = 2;\na = 2kg t;")); err == nil {
+		t.Fatalf("expected err of 2kg t")
+	}
+}
+
+func TestDerivedUnitsCache(t *testing.T) {
+	su := NewUnitManager().(*staticum)
+	count := func() int {
+		n := 0
+		su.derived.Range(func(_, _ any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	if _, ok := su.GetByName("kg/m3"); !ok {
+		t.Fatalf("expected kg/m3")
+	}
+	expected := count()
+	for i, name := range []string{"kg/(m3)", "kg/((m3))", "kg.m-3", "kg m-3", "kg*m^-3", "kg/m^3", "kg/m.m2"} {
+		u, ok := su.GetByName(name)
+		if !ok || u.Name() != "kg/m3" {
+			t.Fatalf("%d: expected kg/m3, got %v", i, u)
+		}
+	}
+	if got := count(); got != expected {
+		t.Fatalf("expected %d derived units, got %d", expected, got)
+	}
+}
+
+// linearPeekMeta is the linear scan of the names and aliases the
+// trie replaced, the aliases are matched case-insensitively if folded.
+func linearPeekMeta(su *staticum, s string) (int, string, bool) {
+	n, name, ok := 0, "", false
+	match := func(cand string, k int) {
+		if k > n || !ok {
+			n, name, ok = k, cand, true
+		}
+	}
+	for cand, u := range su.m {
+		if _, meta := u.(*MetaUnit); meta && strings.HasPrefix(s, cand) {
+			match(cand, len(cand))
+		}
+	}
+	for alias := range su.aliases {
+		if strings.HasPrefix(s, alias) {
+			match(alias, len(alias))
+		}
+		if !su.folded {
+			continue
+		}
+		for k := range s + " " {
+			if k > 0 && k > n && strings.ToLower(s[:k]) == alias {
+				match(alias, k)
+			}
+		}
+	}
+	return n, name, ok
+}
+
+func TestPeekTrie(t *testing.T) {
+	inputs := []string{"kg", "kg;", "kg/t", "Gg/10^3m3", "10^3m3)", "m", "me", "mm", "mmol/kg", "min", "mi",
+		"t.km", "t.kmx", "tonnes", "°C", "Δ°C + 1", "µg", "kWh*2", "passenger.km", "kUSD", "hours", "x", "",
+		"KWH;", "Tonnes", "ΔDEGC", "MW", "mW", "Kilograms * 2"}
+	for _, um := range []UnitManager{StdUm, NewUnitManager(WithCaseInsensitiveUnits(), WithLongUnitNames())} {
+		su := um.(*staticum)
+		cases := append([]string{}, inputs...)
+		// a sample of the names, the linear scan is slow
+		k := 0
+		for name := range su.m {
+			if k++; k%25 == 0 {
+				cases = append(cases, name, name+";", name+"x", name+" * 2", name+"/kg")
+			}
+		}
+		for _, s := range cases {
+			n, u, ok := su.peekMeta(s)
+			en, ename, eok := linearPeekMeta(su, s)
+			if n != en || ok != eok {
+				t.Fatalf("peek %q: expected %d %v, got %d %v", s, en, eok, n, ok)
+			}
+			if eu, _ := su.metaUnit(ename); ok && u != eu {
+				t.Fatalf("peek %q: expected %v, got %v", s, eu, u)
+			}
+		}
+	}
+}
+
+func BenchmarkPeek(b *testing.B) {
+	inputs := []string{"kg", "kg/t.km + 1", "Gg/10^3m3", "(10^3m3/t)", "me", "tonnes", "kg/tonne", "Δ°C + 1", "kWh*2"}
+	for i := 0; i < b.N; i++ {
+		for _, s := range inputs {
+			StdUm.Peek(s)
+		}
+	}
+}
+
+func BenchmarkPeekMeta(b *testing.B) {
+	su := StdUm.(*staticum)
+	inputs := []string{"kg", "tonnes", "10^3m3)", "me", "passenger.km", "Δ°C + 1"}
+	b.Run("trie", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range inputs {
+				su.peekMeta(s)
+			}
+		}
+	})
+	b.Run("linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range inputs {
+				linearPeekMeta(su, s)
+			}
+		}
+	})
+}
+
+func BenchmarkLexer(b *testing.B) {
+	script := strings.Repeat("a = 1000ltr * 0.84kg/ltr + 2.5t - b / 3Gj * 43Tj/Gg, 5 h;\n", 20)
+	for i := 0; i < b.N; i++ {
+		l := newLexer(script)
+		var lval exprSymType
+		for l.Lex(&lval) != eof {
+		}
+	}
 }
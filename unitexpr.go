@@ -0,0 +1,382 @@
+package calcu
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// unitTerm is a meta unit raised to the exponent, e.g., m-3 of kg m-3
+type unitTerm struct {
+	u   *MetaUnit
+	exp int
+}
+
+// unitScanner scans the unit expressions, e.g., kg/(t.km), W.m-2,
+// kg m-3 or m^2. The units are multiplied by `.`, `·`, `*` or the
+// spaces, and divided by `/`, the units after a `/` are all in the
+// denominator, i.e., W/m2.K is W/(m2.K). The exponents are integers
+// after the unit, with or without `^`, e.g., m2, m^2, m-3, m^-3.
+type unitScanner struct {
+	su *staticum
+	s  string
+	i  int
+	// inline is the unit inline of an expression, e.g., 2kg * t,
+	// the `*` out of brackets is a multiplication, not of the unit.
+	inline bool
+	// spaced is whether the spaces multiply, e.g., kg m-3
+	spaced bool
+}
+
+// scanUnitExpr scans the unit expression at the start of s, emit is
+// called with the end and the terms at each point the expression is
+// complete, i.e., the longest expression is the last emitted.
+func (su *staticum) scanUnitExpr(s string, inline, spaced bool, emit func(end int, terms []unitTerm)) {
+	p := &unitScanner{su: su, s: s, inline: inline, spaced: spaced}
+	p.expr(nil, 1, emit)
+}
+
+func (p *unitScanner) expr(terms []unitTerm, sign int, emit func(int, []unitTerm)) ([]unitTerm, bool) {
+	terms, ok := p.factor(terms, sign, emit)
+	if !ok {
+		return terms, false
+	}
+	inDen := false
+	for {
+		if emit != nil {
+			emit(p.i, append([]unitTerm(nil), terms...))
+		}
+		save := p.i
+		op, ok := p.op(!p.inline || emit == nil)
+		if !ok {
+			return terms, true
+		}
+		if op == '/' {
+			inDen = true
+		}
+		fsign := sign
+		if inDen {
+			fsign = -sign
+		}
+		next, ok := p.factor(terms, fsign, emit)
+		if !ok {
+			p.i = save
+			return terms, true
+		}
+		terms = next
+	}
+}
+
+// op scans the operator, it's either '/' or '*' for the products
+func (p *unitScanner) op(star bool) (byte, bool) {
+	rest := p.s[p.i:]
+	switch {
+	case strings.HasPrefix(rest, "/"):
+		p.i++
+		return '/', true
+	case strings.HasPrefix(rest, ".") || star && strings.HasPrefix(rest, "*"):
+		p.i++
+		return '*', true
+	case strings.HasPrefix(rest, "·"):
+		p.i += len("·")
+		return '*', true
+	}
+	// the spaces multiply, e.g., kg m-3
+	if !p.spaced {
+		return 0, false
+	}
+	n := 0
+	for n < len(rest) && (rest[n] == ' ' || rest[n] == '\t') {
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	p.i += n
+	return '*', true
+}
+
+// factor scans a unit with the exponent or a bracketed expression, the
+// unit without the exponent is emitted as well, i.e., the `-3` of m-3
+// might be a subtraction.
+func (p *unitScanner) factor(terms []unitTerm, sign int, emit func(int, []unitTerm)) ([]unitTerm, bool) {
+	save := p.i
+	if strings.HasPrefix(p.s[p.i:], "(") {
+		p.i++
+		inner, ok := p.expr(terms, sign, nil)
+		if !ok || !strings.HasPrefix(p.s[p.i:], ")") {
+			p.i = save
+			return terms, false
+		}
+		p.i++
+		return inner, true
+	}
+	n, u, ok := p.su.peekMeta(p.s[p.i:])
+	if !ok {
+		return terms, false
+	}
+	p.i += n
+	end := p.i
+	exp, ok := p.exponent()
+	if !ok {
+		p.i = save
+		return terms, false
+	}
+	if emit != nil && p.i > end {
+		emit(end, append(append([]unitTerm(nil), terms...), unitTerm{u: u, exp: sign}))
+	}
+	return append(terms, unitTerm{u: u, exp: sign * exp}), true
+}
+
+// exponent scans the exponent of a unit, it's 1 if none
+func (p *unitScanner) exponent() (int, bool) {
+	rest := p.s[p.i:]
+	k := 0
+	caret := strings.HasPrefix(rest, "^")
+	if caret {
+		k++
+	}
+	if k < len(rest) && (rest[k] == '-' || caret && rest[k] == '+') {
+		k++
+	}
+	j := k
+	for j < len(rest) && rest[j] >= '0' && rest[j] <= '9' {
+		j++
+	}
+	if j == k {
+		// no digits, e.g., the `-` of m-x is not an exponent
+		return 1, !caret
+	}
+	exp, err := strconv.Atoi(strings.TrimPrefix(rest[:j], "^"))
+	if err != nil || exp == 0 {
+		return 0, false
+	}
+	p.i += j
+	return exp, true
+}
+
+// resolveTerms resolves the terms to a meta unit or a compound unit
+// of a meta unit per meta unit, the meta unit might be derived of
+// the catalogue, e.g., ft2 of m2, kg.mi of t.km.
+func (su *staticum) resolveTerms(terms []unitTerm) (Unit, bool) {
+	var num, den []unitTerm
+	for _, t := range terms {
+		t = su.flatten(t)
+		if t.exp > 0 {
+			num = mergeTerm(num, t.u, t.exp)
+		} else {
+			den = mergeTerm(den, t.u, -t.exp)
+		}
+	}
+	if len(num) == 0 {
+		return nil, false
+	}
+	nu, ok := su.groupUnit(num)
+	if !ok {
+		return nil, false
+	}
+	if len(den) == 0 {
+		return nu, true
+	}
+	du, ok := su.groupUnit(den)
+	if !ok {
+		return nil, false
+	}
+	cu := newCompoundUnit(nu, du)
+	u, _ := su.derived.LoadOrStore(cu.Name(), cu)
+	return u.(Unit), true
+}
+
+// mergeTerm merges the exponents of the same unit, e.g., m.m is m2,
+// m2.m is m3 of the flattened m^2.m, the units of the numerator and
+// the denominator are not merged, i.e., kg/kg is kept as is.
+func mergeTerm(terms []unitTerm, u *MetaUnit, exp int) []unitTerm {
+	for k := range terms {
+		if terms[k].u == u {
+			terms[k].exp += exp
+			return terms
+		}
+	}
+	return append(terms, unitTerm{u: u, exp: exp})
+}
+
+// flatten resolves the term of a power unit to the power of the
+// base unit, e.g., m2^2 is m^4, km2 is km^2.
+func (su *staticum) flatten(t unitTerm) unitTerm {
+	n := len(t.u.name)
+	if n < 2 || t.u.name[n-1] < '2' || t.u.name[n-1] > '9' {
+		return t
+	}
+	exp := int(t.u.name[n-1] - '0')
+	base, ok := su.metaUnit(t.u.name[:n-1])
+	if !ok {
+		if u, found := su.GetByName(t.u.name[:n-1]); found {
+			base, ok = u.(*MetaUnit)
+		}
+	}
+	// the si of the power unit is the power of the si of the base,
+	// e.g., m2 of m, not Nm3 of Nm.
+	if !ok || t.u.si != base.si+strconv.Itoa(exp) {
+		return t
+	}
+	return unitTerm{u: base, exp: t.exp * exp}
+}
+
+// groupUnit resolves the product of the terms to a meta unit
+func (su *staticum) groupUnit(terms []unitTerm) (*MetaUnit, bool) {
+	if len(terms) > 2 {
+		return nil, false
+	}
+	units := make([]*MetaUnit, 0, len(terms))
+	for _, t := range terms {
+		u := t.u
+		if t.exp != 1 {
+			var ok bool
+			if u, ok = su.powerUnit(t.u, t.exp); !ok {
+				return nil, false
+			}
+		}
+		units = append(units, u)
+	}
+	if len(units) == 1 {
+		return units[0], true
+	}
+	return su.productUnit(units[0], units[1])
+}
+
+// powerUnit resolves u^n, e.g., m3 of m^3, or derives it from
+// the unit of the same dimension, e.g., ft2 of m2.
+func (su *staticum) powerUnit(u *MetaUnit, n int) (*MetaUnit, bool) {
+	name := u.name + strconv.Itoa(n)
+	if mu, ok := su.metaUnit(name); ok {
+		return mu, true
+	}
+	if du, ok := su.derived.Load(name); ok {
+		mu, ok := du.(*MetaUnit)
+		return mu, ok
+	}
+	if u.isAbsolute() {
+		return nil, false
+	}
+	ref, ok := su.powers[powerKey{u.dimension, n}]
+	if !ok {
+		// no dimension of the power, e.g., s2, it's only
+		// convertible to the units of the same si power.
+		ref = powerRef{unit: siRef(u.si + strconv.Itoa(n)), base: siRef(u.si)}
+	}
+	// ref.unit is ref.base^n, e.g., m2 is m^2, the factor
+	// of u^n is of ref.unit scaled by (u/ref.base)^n.
+	f, d := ref.unit.siFactor, ref.unit.siDivisor
+	for k := 0; k < n; k++ {
+		f = f.Mul(u.siFactor).Mul(ref.base.siDivisor)
+		d = d.Mul(u.siDivisor).Mul(ref.base.siFactor)
+	}
+	mu := derivedUnit(name, u.label+"^"+strconv.Itoa(n), ref.unit, f, d)
+	du, _ := su.derived.LoadOrStore(name, mu)
+	return du.(*MetaUnit), true
+}
+
+// productUnit resolves a.b, e.g., t.km, or derives it from the unit
+// of the same dimensions, e.g., kg.mi of t.km.
+func (su *staticum) productUnit(a, b *MetaUnit) (*MetaUnit, bool) {
+	name := a.name + "." + b.name
+	for _, s := range []string{name, b.name + "." + a.name} {
+		if mu, ok := su.metaUnit(s); ok {
+			return mu, true
+		}
+	}
+	if du, ok := su.derived.Load(name); ok {
+		mu, ok := du.(*MetaUnit)
+		return mu, ok
+	}
+	if a.isAbsolute() || b.isAbsolute() {
+		return nil, false
+	}
+	ref, ok := su.products[[2]Dimension{a.dimension, b.dimension}]
+	if !ok {
+		ref, ok = su.products[[2]Dimension{b.dimension, a.dimension}]
+		if ok {
+			a, b = b, a
+		}
+	}
+	if !ok {
+		// no dimension of the product, e.g., h.km, it's only
+		// convertible to the units of the same si product.
+		si := []string{a.si, b.si}
+		sort.Strings(si)
+		ref = productRef{unit: siRef(strings.Join(si, ".")), a: siRef(a.si), b: siRef(b.si)}
+	}
+	// ref.unit is ref.a.ref.b, e.g., t.km, the factor of
+	// a.b is of ref.unit scaled by a/ref.a and b/ref.b.
+	f := ref.unit.siFactor.Mul(a.siFactor).Mul(b.siFactor).Mul(ref.a.siDivisor).Mul(ref.b.siDivisor)
+	d := ref.unit.siDivisor.Mul(a.siDivisor).Mul(b.siDivisor).Mul(ref.a.siFactor).Mul(ref.b.siFactor)
+	mu := derivedUnit(name, a.label+" "+b.label, ref.unit, f, d)
+	du, _ := su.derived.LoadOrStore(name, mu)
+	return du.(*MetaUnit), true
+}
+
+// siRef is the unit of si, the factor is 1
+func siRef(si string) *MetaUnit {
+	return &MetaUnit{dimension: DimInvalid, si: si, siFactor: decimal.NewFromInt(1), siDivisor: decimal.NewFromInt(1)}
+}
+
+func derivedUnit(name, label string, ref *MetaUnit, f, d decimal.Decimal) *MetaUnit {
+	return &MetaUnit{
+		name:      name,
+		label:     label,
+		dimension: ref.dimension,
+		si:        ref.si,
+		siFactor:  f,
+		siDivisor: d,
+		siOffset:  decimal.Zero,
+	}
+}
+
+type powerKey struct {
+	dim Dimension
+	exp int
+}
+
+// powerRef is a unit of the catalogue as a power of
+// another unit of the catalogue, e.g., m2 of m
+type powerRef struct {
+	unit *MetaUnit
+	base *MetaUnit
+}
+
+// productRef is a unit of the catalogue as a product
+// of two units of the catalogue, e.g., t.km of t and km
+type productRef struct {
+	unit *MetaUnit
+	a, b *MetaUnit
+}
+
+// indexDerivable indexes the units of the catalogue the other
+// units are derived from, the first one of the dimension wins.
+func (su *staticum) indexDerivable(units []*MetaUnit) {
+	su.powers = make(map[powerKey]powerRef)
+	su.products = make(map[[2]Dimension]productRef)
+	for _, u := range units {
+		if n := len(u.name); n > 1 && u.name[n-1] >= '2' && u.name[n-1] <= '9' {
+			if base, ok := su.m[u.name[:n-1]].(*MetaUnit); ok {
+				key := powerKey{dim: base.dimension, exp: int(u.name[n-1] - '0')}
+				if _, dup := su.powers[key]; !dup {
+					su.powers[key] = powerRef{unit: u, base: base}
+				}
+			}
+		}
+		if an, bn, ok := strings.Cut(u.name, "."); ok {
+			a, aok := su.m[an].(*MetaUnit)
+			b, bok := su.m[bn].(*MetaUnit)
+			if !aok || !bok {
+				continue
+			}
+			key := [2]Dimension{a.dimension, b.dimension}
+			if _, dup := su.products[key]; !dup {
+				su.products[key] = productRef{unit: u, a: a, b: b}
+			}
+		}
+	}
+}